@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -15,7 +16,10 @@ import (
 	"paperMC_backend/internal/auth"
 	"paperMC_backend/internal/config"
 	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
 	"paperMC_backend/internal/minecraft"
+	"paperMC_backend/internal/scheduler"
+	"paperMC_backend/internal/updater"
 	"paperMC_backend/web"
 )
 
@@ -26,7 +30,45 @@ func main() {
 		log.Fatalf("CRITICAL ERROR, %v", err)
 	}
 	defer store.Close()
-	mcServer := minecraft.NewServer(cfg.WorkDir, cfg.JarFile, cfg.RAM, store)
+
+	// Java runtimes
+	javaRegistry := java.NewRegistry(cfg.JavaDirs)
+	for _, rt := range javaRegistry.Scan() {
+		log.Printf("[Init] Found Java %d (%s) at %s", rt.Major, rt.Vendor, rt.Path)
+	}
+
+	// Server instances, the env config seeds the default one on first boot
+	servers := minecraft.NewServerManager(store, javaRegistry, minecraft.ServerOptions{
+		Restart: minecraft.RestartPolicy{
+			Enabled:    cfg.AutoRestart,
+			MaxRetries: cfg.RestartMaxRetries,
+			Window:     cfg.RestartWindow,
+			BaseDelay:  cfg.RestartBackoff,
+			MaxDelay:   cfg.RestartBackoffMax,
+		},
+		StartupTimeout: cfg.StartupTimeout,
+		StopTimeout:    cfg.StopTimeout,
+		TermTimeout:    cfg.TermTimeout,
+		Detach:         cfg.Detach,
+		RCON:           cfg.RCON,
+		Retention: minecraft.LogRetention{
+			MaxAge:   cfg.LogRetention,
+			MaxLines: cfg.LogRetentionLines,
+		},
+	})
+	seed := database.Instance{
+		ID:         cfg.DefaultServer,
+		Name:       cfg.DefaultServer,
+		WorkDir:    cfg.WorkDir,
+		JarFile:    cfg.JarFile,
+		RAM:        cfg.RAM,
+		Port:       seedPort(cfg),
+		JVMProfile: minecraft.DefaultProfile,
+		ServerArgs: []string{"nogui"},
+	}
+	if err := servers.Load(seed); err != nil {
+		log.Fatalf("CRITICAL ERROR, failed to load servers: %v", err)
+	}
 
 	// --- BOOTSTRA ADMIN USER ----
 	// IF ADMIN_PASS is ser, ensure the user exists
@@ -59,7 +101,18 @@ func main() {
 		log.Printf("[Init] Warning: ADMIN_PASS is mepty. No admin user created")
 	}
 
-	mcHandler := api.NewServerHandler(mcServer, store)
+	restartScheduler := scheduler.New(servers, store)
+	if err := restartScheduler.Reload(); err != nil {
+		log.Printf("[Init] Failed to load restart schedules: %v", err)
+	}
+	defer restartScheduler.Close()
+
+	channel, err := updater.ParseChannel(cfg.UpdateChannel)
+	if err != nil {
+		log.Fatalf("[Init] UPDATE_CHANNEL: %v", err)
+	}
+	paper := updater.NewClient(cfg.PaperAPI)
+	mcHandler := api.NewServerHandler(servers, store, restartScheduler, javaRegistry, cfg.ServersDir, paper, channel)
 	mux := http.NewServeMux()
 
 	// Prepare the forwared Files
@@ -79,10 +132,47 @@ func main() {
 	// Protected Routes in a Map
 	// Key = Path, Value = Handler Function
 	protectedRoutes := map[string]http.HandlerFunc{
+		// Server instances
+		"GET /api/servers":         mcHandler.HandleListServers,
+		"POST /api/servers":        mcHandler.HandleCreateServer,
+		"GET /api/servers/{id}":    mcHandler.HandleGetServer,
+		"PUT /api/servers/{id}":    mcHandler.HandleUpdateServer,
+		"DELETE /api/servers/{id}": mcHandler.HandleDeleteServer, // ?files=true
+
+		// Player Manager - Rejected (DB)
+		"GET /api/players/rejected":    mcHandler.HandleGetRejected,
+		"DELETE /api/players/rejected": mcHandler.HandleDeleteRejected,
+
+		// JVM flag profiles
+		"GET /api/jvm/profiles":    mcHandler.HandleGetProfiles,
+		"POST /api/jvm/profiles":   mcHandler.HandleCreateProfile,
+		"PUT /api/jvm/profiles":    mcHandler.HandleUpdateProfile, // ?name=
+		"DELETE /api/jvm/profiles": mcHandler.HandleDeleteProfile, // ?name=
+
+		// Java runtimes
+		"GET /api/java/runtimes":       mcHandler.HandleGetRuntimes,
+		"POST /api/java/runtimes/scan": mcHandler.HandleScanRuntimes,
+
+		// Background jobs (updates)
+		"GET /api/jobs/{id}":    mcHandler.HandleGetJob,
+		"DELETE /api/jobs/{id}": mcHandler.HandleCancelJob,
+	}
+
+	// Per server routes. Each one is served under /api/servers/{id}/... and,
+	// for the default server, under its original path.
+	serverRoutes := map[string]http.HandlerFunc{
 		"GET /status": mcHandler.HandleStatus,
 		"GET /logs":   mcHandler.HandleLogs,
 		"GET /config": mcHandler.GetConfig,
-		"GET /ws":     mcHandler.SocketHandler,
+
+		// Console log archive
+		"GET /api/logs": mcHandler.HandleSearchLogs, // ?q=&level=&since=&until=&limit=
+
+		// Paper's own log files
+		"GET /api/logs/files":                 mcHandler.HandleListLogFiles,
+		"GET /api/logs/files/{name}":          mcHandler.HandleReadLogFile, // ?grep=&from=&to=&tail=
+		"GET /api/logs/files/{name}/download": mcHandler.HandleDownloadLogFile,
+		"GET /ws":                             mcHandler.SocketHandler,
 
 		// Player Manager - WhiteList
 		"GET /api/players":    mcHandler.HandleGetPlayers,
@@ -98,9 +188,18 @@ func main() {
 		"GET /api/players/ops":  mcHandler.HandleGetOps,
 		"POST /api/players/ops": mcHandler.HandleOpPlayer, // ?action=add|remove
 
-		// Player Manager - Rejected (DB)
-		"GET /api/players/rejected":    mcHandler.HandleGetRejected,
-		"DELETE /api/players/rejected": mcHandler.HandleDeleteRejected,
+		// Scheduled restarts
+		"GET /api/schedules/restart":    mcHandler.HandleGetSchedules,
+		"POST /api/schedules/restart":   mcHandler.HandleCreateSchedule,
+		"PUT /api/schedules/restart":    mcHandler.HandleUpdateSchedule, // ?id=
+		"DELETE /api/schedules/restart": mcHandler.HandleDeleteSchedule, // ?id=
+
+		// Launch options
+		"GET /api/jvm/selection":   mcHandler.HandleGetLaunchOptions,
+		"POST /api/jvm/selection":  mcHandler.HandleSetLaunchOptions,
+		"GET /api/jvm/preview":     mcHandler.HandlePreview,
+		"GET /api/java/selection":  mcHandler.HandleGetJava,
+		"POST /api/java/selection": mcHandler.HandleSetJava,
 
 		"POST /command":       mcHandler.SendCommand,
 		"POST /whitelist_add": mcHandler.WhiteListing,
@@ -108,6 +207,14 @@ func main() {
 		"POST /stop":          mcHandler.Stop,
 		"POST /config":        mcHandler.PostConfig,
 		"POST /update":        mcHandler.HandleUpdate,
+
+		// Version and build browser
+		"GET /api/updates/versions":                  mcHandler.HandleListVersions,
+		"GET /api/updates/versions/{version}/builds": mcHandler.HandleListBuilds, // ?channel=&limit=
+	}
+	for path, handler := range serverRoutes {
+		protectedRoutes[path] = handler
+		protectedRoutes[scopedPattern(path)] = handler
 	}
 
 	// Register all the protected routes
@@ -140,8 +247,42 @@ func main() {
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	sig := <-c
 	fmt.Printf("Receiving Signal [%v]. Shutting down...\n", sig)
-	if err := mcServer.Stop(); err != nil {
-		log.Printf("Error stopping the server: %v", err)
+	if cfg.Detach && os.Getpid() != 1 {
+		// Detached servers keep running, the next backend re-attaches to them.
+		// As PID 1 (Docker) the container dies with us and java with it.
+		fmt.Println("Leaving Minecraft servers running (MC_DETACH=true)")
+	} else {
+		for id, err := range servers.StopAll(cfg.StopTimeout) {
+			if err != nil {
+				log.Printf("Error stopping server %s: %v", id, err)
+			}
+		}
 	}
+	servers.Close()
 	fmt.Printf("Server stopped gracefully [%v]", sig)
 }
+
+// scopedPattern maps "GET /api/players" to "GET /api/servers/{id}/players"
+// and "GET /status" to "GET /api/servers/{id}/status".
+func scopedPattern(pattern string) string {
+	method, path, _ := strings.Cut(pattern, " ")
+	if path == "/logs" {
+		// Reserve /api/servers/{id}/logs for the log APIs
+		path = "/logs/stream"
+	}
+	return method + " /api/servers/{id}" + strings.TrimPrefix(path, "/api")
+}
+
+// seedPort picks the port of the default server: MC_PORT, else the one
+// already in its server.properties, else the Minecraft default.
+func seedPort(cfg *config.Config) int {
+	if cfg.MCPort != 0 {
+		return cfg.MCPort
+	}
+	if props, err := config.LoadProperties(cfg.WorkDir); err == nil {
+		if port, err := strconv.Atoi(props["server-port"]); err == nil {
+			return port
+		}
+	}
+	return 25565
+}
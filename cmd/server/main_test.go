@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestScopedPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    string
+	}{
+		{"Root route", "GET /status", "GET /api/servers/{id}/status"},
+		{"API route", "GET /api/players", "GET /api/servers/{id}/players"},
+		{"Nested API route", "DELETE /api/players/banned", "DELETE /api/servers/{id}/players/banned"},
+		{"Wildcard", "GET /api/logs/files/{name}", "GET /api/servers/{id}/logs/files/{name}"},
+		{"Console stream", "GET /logs", "GET /api/servers/{id}/logs/stream"},
+		{"Log search", "GET /api/logs", "GET /api/servers/{id}/logs"},
+		{"Websocket", "GET /ws", "GET /api/servers/{id}/ws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scopedPattern(tt.pattern); got != tt.want {
+				t.Errorf("[TEST] scopedPattern(%q) = %q, want: %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	// The scoped routes must not collide with each other or with the
+	// instance routes, ServeMux panics on a conflict
+	mux := http.NewServeMux()
+	noop := func(http.ResponseWriter, *http.Request) {}
+	for _, pattern := range []string{"GET /api/servers/{id}", "PUT /api/servers/{id}", "DELETE /api/servers/{id}"} {
+		mux.HandleFunc(pattern, noop)
+	}
+	for _, tt := range tests {
+		mux.HandleFunc(tt.pattern, noop)
+		mux.HandleFunc(scopedPattern(tt.pattern), noop)
+	}
+}
@@ -1,23 +1,37 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"paperMC_backend/internal/config"
 	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
+	"paperMC_backend/internal/jobs"
 	"paperMC_backend/internal/minecraft"
+	"paperMC_backend/internal/scheduler"
 	"paperMC_backend/internal/updater"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 type Handler struct {
-	mc       *minecraft.Server
-	updateMu sync.Mutex
-	store    database.Store
+	servers     *minecraft.ServerManager
+	updateLocks sync.Map // server ID -> *sync.Mutex
+	store       database.Store
+	scheduler   *scheduler.Scheduler
+	java        *java.Registry
+	serversDir  string // parent of the work dirs of new servers
+	paper       *updater.Client
+	jobs        *jobs.Manager
+	channel     updater.Channel // default release channel of /update
 }
 
 func (h *Handler) BasicAuth(next http.Handler, user, pass string) http.Handler {
@@ -35,28 +49,77 @@ func (h *Handler) BasicAuth(next http.Handler, user, pass string) http.Handler {
 
 }
 
-func NewServerHandler(mcServer *minecraft.Server, store database.Store) *Handler {
+func NewServerHandler(servers *minecraft.ServerManager, store database.Store, sched *scheduler.Scheduler, runtimes *java.Registry, serversDir string, paper *updater.Client, channel updater.Channel) *Handler {
 	return &Handler{
-		mc:       mcServer,
-		updateMu: sync.Mutex{},
-		store:    store,
+		servers:    servers,
+		store:      store,
+		scheduler:  sched,
+		java:       runtimes,
+		serversDir: serversDir,
+		paper:      paper,
+		jobs:       jobs.NewManager(),
+		channel:    channel,
 	}
 }
 
+// server resolves the instance addressed by /api/servers/{id}/... The legacy
+// routes have no {id} and get the default instance.
+func (h *Handler) server(w http.ResponseWriter, r *http.Request) (*minecraft.Server, bool) {
+	mc, ok := h.servers.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return nil, false
+	}
+	return mc, true
+}
+
+// updateLock returns the mutex that serializes updates of one server.
+func (h *Handler) updateLock(id string) *sync.Mutex {
+	lock, _ := h.updateLocks.LoadOrStore(id, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
 type StatusResponse struct {
 	Status string `json:"status"`
 }
 
+// CommandResponse is the answer of POST /command?sync=true
+type CommandResponse struct {
+	Status string   `json:"status"`
+	Output string   `json:"output"` // what the server answered, without color codes
+	Lines  []string `json:"lines"`
+}
+
+type StopResponse struct {
+	Status string              `json:"status"`
+	Stage  minecraft.StopStage `json:"stage"`
+}
+
 type CommandRequest struct {
 	Command string `json:"command"`
 }
 
 type UpdateRequest struct {
-	Version string `json:"version"`
+	Version string `json:"version,omitempty"` // the installed version when empty
+	Channel string `json:"channel,omitempty"` // RECOMMENDED, STABLE, BETA or ALPHA, the server default when empty
+	Build   int    `json:"build,omitempty"`   // pin this build instead of the latest of the channel
+	Force   bool   `json:"force,omitempty"`   // allow going back to an older Minecraft version
+}
+
+// UpdateResponse is the job of an accepted update.
+type UpdateResponse struct {
+	jobs.Job
+	Warning string `json:"warning,omitempty"` // set when the update changes the Minecraft version
 }
 
 func (h *Handler) HandleStatus(w http.ResponseWriter, r *http.Request) {
-	vitals := h.mc.GetVitals()
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	vitals := mc.GetVitals()
+	// The jar is only read again when it changed
+	vitals.Installed = updater.DetectInstalled(mc.WorkDir, mc.JarFile)
 
 	// 2. Send as JSON
 	w.Header().Set("Content-type", "application/json")
@@ -66,6 +129,10 @@ func (h *Handler) HandleStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) WhiteListing(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	var req = CommandRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -76,7 +143,7 @@ func (h *Handler) WhiteListing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.mc.WhiteListUser(req.Command); err != nil {
+	if err := mc.WhiteListUser(req.Command); err != nil {
 		http.Error(w, "Error sending Command", http.StatusBadRequest)
 		return
 	}
@@ -86,6 +153,10 @@ func (h *Handler) WhiteListing(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) SendCommand(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	var req = CommandRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -97,7 +168,24 @@ func (h *Handler) SendCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.mc.SendCommand(req.Command); err != nil {
+	// ?sync=true returns the output, over RCON only: the console can't tell
+	// the answer from what else is printed
+	if r.URL.Query().Get("sync") == "true" {
+		lines, err := mc.CommandSync(req.Command)
+		if errors.Is(err, minecraft.ErrNoRCON) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Error running command: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-type", "application/json")
+		json.NewEncoder(w).Encode(CommandResponse{Status: "ok", Output: strings.Join(lines, "\n"), Lines: lines})
+		return
+	}
+
+	if err := mc.SendCommand(req.Command); err != nil {
 		http.Error(w, "Error sending Command", http.StatusBadRequest)
 		return
 	}
@@ -107,41 +195,115 @@ func (h *Handler) SendCommand(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Start(w http.ResponseWriter, r *http.Request) {
-
-	if err := h.mc.Start(); err != nil {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	if err := mc.Start(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	response := StatusResponse{Status: "200 Server started"}
+
+	// ?wait=true blocks until the "Done" line (or the boot fails)
+	if r.URL.Query().Get("wait") == "true" {
+		if err := mc.WaitReady(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		response.Status = "200 Server running"
+	}
 	w.Header().Set("Content-type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func (h *Handler) Stop(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	// ?timeout=90 (seconds) or ?timeout=2m overrides the default grace period
+	timeout := mc.StopTimeout
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		parsed, err := parseTimeout(raw)
+		if err != nil {
+			http.Error(w, "Invalid timeout", http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
 
-	if err := h.mc.Stop(); err != nil {
+	stage, err := mc.StopWithTimeout(timeout)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	response := StatusResponse{Status: "200 Server stopped"}
+	response := StopResponse{Status: "200 Server stopped", Stage: stage}
 	w.Header().Set("Content-type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *Handler) HandleLogs(w http.ResponseWriter, r *http.Request) {
+func parseTimeout(raw string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(raw)
+}
 
+func (h *Handler) HandleLogs(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
 	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return
+	}
+	// The browser sends Last-Event-ID by itself when it reconnects,
+	// a fresh connection only gets the live lines
+	cursor, err := cursorFrom(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var (
+		gap     minecraft.Message
+		history []minecraft.LogLine
+		sub     *minecraft.Subscription
+	)
+	if cursor > 0 {
+		gap, history, sub = mc.Resume(cursor, minecraft.SubscriberBuffer, minecraft.DropWithGap)
+	} else {
+		sub = mc.Hub.Subscribe(minecraft.SubscriberBuffer, minecraft.DropWithGap)
+	}
+	defer sub.Close()
+
+	if gap.Kind == minecraft.KindGap {
+		writeSSEGap(w, gap)
+	}
+	for _, line := range history {
+		writeSSELine(w, line)
+	}
+	flusher.Flush()
+
 	for {
-		if !ok {
-			return
-		}
 		select {
-		case response := <-h.mc.LogChan:
-			fmt.Fprintf(w, "data: %s\n\n", response)
+		case msg, open := <-sub.C():
+			if !open {
+				return
+			}
+			switch msg.Kind {
+			case minecraft.KindLog:
+				writeSSELine(w, *msg.Log)
+			case minecraft.KindGap:
+				writeSSEGap(w, msg)
+			default:
+				continue // Lifecycle events and jobs are on the websocket
+			}
 			flusher.Flush()
 		case <-r.Context().Done():
 			return
@@ -149,8 +311,26 @@ func (h *Handler) HandleLogs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeSSELine sends a console line with its seq as the event id.
+func writeSSELine(w http.ResponseWriter, line minecraft.LogLine) {
+	if line.Seq > 0 {
+		fmt.Fprintf(w, "id: %d\n", line.Seq)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", line.Text)
+}
+
+// writeSSEGap sends the gap marker as JSON: {"kind":"gap","dropped":3,"from_seq":10,"to_seq":12}
+func writeSSEGap(w http.ResponseWriter, gap minecraft.Message) {
+	data, _ := json.Marshal(gap)
+	fmt.Fprintf(w, "event: gap\ndata: %s\n\n", data)
+}
+
 func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
-	config, err := config.LoadProperties(h.mc.WorkDir)
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	config, err := config.LoadProperties(mc.WorkDir)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -160,13 +340,17 @@ func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) PostConfig(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	var data map[string]string
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	if err := config.SaveProperties(h.mc.WorkDir, data); err != nil {
+	if err := config.SaveProperties(mc.WorkDir, data); err != nil {
 		http.Error(w, "Failed to save config"+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -175,33 +359,83 @@ func (h *Handler) PostConfig(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
-	// 0. Try Lock, only one Update at a time, otherwise return 409
-	if !h.updateMu.TryLock() {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	// 0. Try Lock, only one Update at a time, otherwise return 409.
+	// The job releases it when it ends.
+	updateMu := h.updateLock(mc.ID)
+	if !updateMu.TryLock() {
 		http.Error(w, "Update already in progress", http.StatusConflict)
 		return
 	}
-	defer h.updateMu.Unlock()
+	started := false
+	defer func() {
+		if !started {
+			updateMu.Unlock()
+		}
+	}()
 
-	// 1. decode the request to get the version
+	// 1. decode the request to get the version, an empty body updates the
+	// installed version to its latest build
 	var version = UpdateRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&version); err != nil {
-		if err == io.EOF {
-			http.Error(w, "Empty request body", http.StatusBadRequest)
-			return
-		}
+	if err := json.NewDecoder(r.Body).Decode(&version); err != nil && err != io.EOF {
 		msg := `Invalid JSON. Expected format: {"version": "1.21.10"}`
 		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
-	// 2. Get the latest build info
-	latestBuild, latestFileName, latestHash, err := updater.GetLatestBuild(version.Version)
+	installed := updater.DetectInstalled(mc.WorkDir, mc.JarFile)
+	if version.Version == "" {
+		if installed == nil {
+			http.Error(w, "Installed version unknown, send {\"version\": \"1.21.10\"}", http.StatusBadRequest)
+			return
+		}
+		version.Version = installed.Version
+	}
+
+	// 1b. Changing the Minecraft version converts the worlds, there is no way back
+	var warning string
+	if installed != nil && version.Version != installed.Version {
+		if updater.CompareVersions(version.Version, installed.Version) < 0 && !version.Force {
+			msg := fmt.Sprintf("Downgrade from %s to %s can corrupt the worlds, send \"force\": true to do it anyway", installed.Version, version.Version)
+			http.Error(w, msg, http.StatusConflict)
+			return
+		}
+		warning = fmt.Sprintf("Minecraft version changes from %s to %s, worlds are converted on the next start and can't be opened by %s again. Make a backup first.",
+			installed.Version, version.Version, installed.Version)
+	}
+	// 2. Get the pinned build or the latest of the channel
+	var build updater.Build
+	var err error
+	channel := h.channel
+	if version.Channel != "" {
+		if channel, err = updater.ParseChannel(version.Channel); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if version.Build > 0 {
+		build, err = h.paper.Build(r.Context(), version.Version, version.Build)
+	} else {
+		build, err = h.paper.LatestBuild(r.Context(), version.Version, channel)
+	}
+	if errors.Is(err, updater.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
-	h.mc.Broadcast(fmt.Sprintf("[System] Found Build %d. Hash: %s", latestBuild, latestHash))
+	download, err := build.Server()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	mc.Broadcast(fmt.Sprintf("[System] Found %s Build %d. Hash: %s", build.Channel, build.ID, download.Checksums.SHA256))
 	// 3. Get old server.jar sha256 hash
-	fullPath := filepath.Join(h.mc.WorkDir, h.mc.JarFile)
+	fullPath := filepath.Join(mc.WorkDir, mc.JarFile)
 	hash, err := updater.GetFileHash(fullPath)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -209,57 +443,60 @@ func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4. Compare
-	if latestHash == hash {
-		h.mc.Broadcast("Latest build already in use")
+	if download.Checksums.SHA256 == hash {
+		mc.Broadcast("Latest build already in use")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(StatusResponse{Status: ""})
 		return
 	}
-	// 5. Update
 
-	// a. Download to server.jar.tmp
-	h.mc.Broadcast("[System] Downloading update...")
-	err = updater.DownloadJar(
-		version.Version, latestBuild, latestFileName, h.mc.WorkDir)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	// 5. Update in the background, progress goes to the websocket and
+	// GET /api/jobs/{id}
+	bootTimeout := mc.StartupTimeout
+	if bootTimeout <= 0 {
+		bootTimeout = 5 * time.Minute
 	}
-
-	// b. Stop server
-	h.mc.Broadcast("[System] Download complete. Stopping server...")
-	h.mc.SendCommand("msg @a Closing Server")
-	if err := h.mc.Stop(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if warning != "" {
+		mc.Broadcast("[WARN] " + warning)
 	}
+	job := h.jobs.Start("update", mc.ID, mc.PublishJob, func(ctx context.Context, job *jobs.Handle) (string, error) {
+		defer updateMu.Unlock()
+
+		// a. Download next to server.jar and check the SHA-256 the API announced
+		job.Stage("downloading")
+		mc.Broadcast("[System] Downloading update...")
+		newPath, err := h.paper.Download(ctx, build, mc.WorkDir, job.Progress)
+		if ctx.Err() != nil {
+			mc.Broadcast("[System] Update cancelled")
+			return "", ctx.Err()
+		}
+		if err != nil {
+			return "", err
+		}
+		mc.Broadcast("[System] Download verified.")
 
-	// c. Move old server.jar file in case failure later
-	oldPath := filepath.Join(h.mc.WorkDir, h.mc.JarFile)
-	oldPathTemp := oldPath + ".tmp"
-	if err := os.Rename(oldPath, oldPathTemp); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+		// b. No cancelling once the server goes down
+		if err := job.Commit(); err != nil {
+			os.Remove(newPath)
+			mc.Broadcast("[System] Update cancelled")
+			return "", err
+		}
 
-	// d. Rename downloaded file
-	// 	if OK move forward
-	// 	if Not OK start server
-	newPath := filepath.Join(h.mc.WorkDir, latestFileName)
-	if err := os.Rename(newPath, oldPath); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		if err := os.Rename(oldPathTemp, oldPath); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+		// c. Stop, swap, start and wait for "Done", the previous jar comes
+		// back if the new one doesn't boot
+		if mc.Active() {
+			mc.SendCommand("msg @a Closing Server")
 		}
-	}
+		if err := updater.Install(context.Background(), mc, fullPath, newPath, bootTimeout, job.Stage); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Updated to build %d", build.ID), nil
+	})
+	started = true
 
-	// e. Start server
-	h.mc.Broadcast("[System] Files swapped. Restarting server...")
-	if err := h.mc.Start(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	// f. Return 200
-	w.WriteHeader(http.StatusOK)
+	// 6. Return 202 with the job to follow
+	w.Header().Set("Content-type", "application/json")
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(UpdateResponse{Job: job, Warning: warning})
 }
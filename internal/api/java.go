@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+
+	"paperMC_backend/internal/java"
+)
+
+type JavaSelectRequest struct {
+	Path string `json:"path"` // java executable or JDK home, empty = java from PATH
+}
+
+type JavaSelectResponse struct {
+	Runtime java.Runtime `json:"runtime"`
+	Warning string       `json:"warning,omitempty"`
+}
+
+func (h *Handler) HandleGetRuntimes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.java.List())
+}
+
+func (h *Handler) HandleScanRuntimes(w http.ResponseWriter, r *http.Request) {
+	runtimes := h.java.Scan()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runtimes)
+}
+
+func (h *Handler) HandleGetJava(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mc.Java)
+}
+
+func (h *Handler) HandleSetJava(w http.ResponseWriter, r *http.Request) {
+	instance, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	var req JavaSelectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	rt, err := h.java.Resolve(req.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	instance.JavaPath = rt.Path
+	if err := h.servers.Update(instance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Selecting is allowed, Start is the one that refuses an incompatible jar
+	response := JavaSelectResponse{Runtime: rt}
+	if err := java.CheckCompatible(rt, filepath.Join(instance.WorkDir, instance.JarFile)); err != nil {
+		response.Warning = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
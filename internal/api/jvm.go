@@ -0,0 +1,183 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/minecraft"
+)
+
+type LaunchOptions struct {
+	Profile string   `json:"profile"`
+	Args    []string `json:"args"`
+}
+
+type PreviewResponse struct {
+	Command     []string `json:"command"`
+	CommandLine string   `json:"command_line"`
+	Warnings    []string `json:"warnings"`
+}
+
+// --- PROFILES ---
+
+func (h *Handler) HandleGetProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := minecraft.ListProfiles(h.store)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profiles)
+}
+
+func (h *Handler) HandleCreateProfile(w http.ResponseWriter, r *http.Request) {
+	var profile database.JVMProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	profile.Builtin = false
+	if err := minecraft.ValidateProfile(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.CreateJVMProfile(&profile); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func (h *Handler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var profile database.JVMProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	profile.Name = r.URL.Query().Get("name")
+	profile.Builtin = false
+	if err := minecraft.ValidateProfile(profile); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.store.UpdateJVMProfile(&profile); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Keep the servers using it in sync
+	h.servers.RefreshProfile(profile)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func (h *Handler) HandleDeleteProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "Profile name required", http.StatusBadRequest)
+		return
+	}
+	if users := h.servers.ProfileInUse(name); len(users) > 0 {
+		http.Error(w, "Profile is in use by "+strings.Join(users, ", "), http.StatusConflict)
+		return
+	}
+	if err := h.store.DeleteJVMProfile(name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Profile not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "Profile deleted"})
+}
+
+// --- SELECTION ---
+
+func (h *Handler) HandleGetLaunchOptions(w http.ResponseWriter, r *http.Request) {
+	instance, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LaunchOptions{Profile: instance.JVMProfile, Args: instance.ServerArgs})
+}
+
+func (h *Handler) HandleSetLaunchOptions(w http.ResponseWriter, r *http.Request) {
+	instance, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	var req LaunchOptions
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Args == nil {
+		req.Args = []string{"nogui"}
+	}
+	instance.JVMProfile = req.Profile
+	instance.ServerArgs = req.Args
+	if err := h.servers.Update(instance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "Launch options saved, applied on next start"})
+}
+
+// HandlePreview shows the final command line. Without parameters it shows
+// the current selection, ?profile=, ?ram= and ?args= (space separated) preview changes.
+func (h *Handler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	profile := mc.Profile
+	if name := query.Get("profile"); name != "" {
+		p, err := minecraft.LookupProfile(h.store, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		profile = p
+	}
+	ram := mc.RAM
+	if query.Has("ram") {
+		ram = query.Get("ram")
+	}
+	args := mc.Args
+	if query.Has("args") {
+		args = strings.Fields(query.Get("args"))
+	}
+
+	heap, warnings, err := minecraft.ValidateHeap(ram)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	command := minecraft.BuildCommandLine(mc.Java.Path, ram, minecraft.ProfileFlags(profile, heap, mc.Java.Major), mc.JarFile, args)
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PreviewResponse{
+		Command:     command,
+		CommandLine: strings.Join(command, " "),
+		Warnings:    warnings,
+	})
+}
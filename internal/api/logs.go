@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/logfiles"
+)
+
+const (
+	defaultLogLimit = 200
+	maxLogLimit     = 5000
+)
+
+// logLevels from the least to the most severe, ?level= is a minimum.
+var logLevels = []string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// HandleSearchLogs searches the console archive:
+// ?q= full-text query, ?level= minimum level, ?since=/?until= RFC3339 times or
+// durations ago ("24h"), ?limit= (newest lines win, returned oldest first).
+func (h *Handler) HandleSearchLogs(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	search := database.ConsoleLogQuery{
+		InstanceID: mc.ID,
+		Text:       strings.TrimSpace(query.Get("q")),
+		Limit:      defaultLogLimit,
+	}
+
+	if raw := query.Get("level"); raw != "" {
+		levels, err := levelsFrom(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		search.Levels = levels
+	}
+	var err error
+	if search.Since, err = parseLogTime(query.Get("since")); err != nil {
+		http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if search.Until, err = parseLogTime(query.Get("until")); err != nil {
+		http.Error(w, "Invalid until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		search.Limit = min(limit, maxLogLimit)
+	}
+
+	logs, err := h.store.SearchConsoleLogs(search)
+	if err != nil {
+		if search.Text != "" && strings.Contains(err.Error(), "fts5") {
+			http.Error(w, "Invalid search query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logs)
+}
+
+// levelsFrom returns the given level and the more severe ones.
+func levelsFrom(level string) ([]string, error) {
+	level = strings.ToUpper(level)
+	if level == "WARNING" {
+		level = "WARN"
+	}
+	for i, l := range logLevels {
+		if l == level {
+			return logLevels[i:], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown level %s, use one of %s", level, strings.Join(logLevels, ", "))
+}
+
+// parseLogTime accepts RFC3339 ("2025-01-15T10:00:00Z") or a duration ago ("90m").
+func parseLogTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	ago, err := time.ParseDuration(raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-ago), nil
+}
+
+// --- LOG FILES ---
+
+// HandleListLogFiles lists logs/latest.log and the rotated .log.gz files.
+func (h *Handler) HandleListLogFiles(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	files, err := logfiles.List(mc.WorkDir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(files)
+}
+
+// HandleReadLogFile streams a log file as text, decompressed:
+// ?grep= regular expression, ?ignore_case=true, ?invert=true,
+// ?from=&to= line range, ?tail= last N lines, ?limit=, ?numbers=true prefixes line numbers.
+func (h *Handler) HandleReadLogFile(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	// 1. Build the filter
+	filter := logfiles.Filter{Invert: query.Get("invert") == "true"}
+	if pattern := query.Get("grep"); pattern != "" {
+		if query.Get("ignore_case") == "true" {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			http.Error(w, "Invalid grep pattern: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Pattern = re
+	}
+	for param, target := range map[string]*int{"from": &filter.From, "to": &filter.To, "tail": &filter.Tail, "limit": &filter.Limit} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid "+param, http.StatusBadRequest)
+			return
+		}
+		*target = n
+	}
+
+	// 2. Open and stream
+	file, err := logfiles.Open(mc.WorkDir, r.PathValue("name"))
+	if errors.Is(err, logfiles.ErrNotFound) {
+		http.Error(w, "Log file not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	numbers := query.Get("numbers") == "true"
+	flusher, canFlush := w.(http.Flusher)
+	written := 0
+	err = logfiles.Scan(file, filter, func(line logfiles.Line) error {
+		var err error
+		if numbers {
+			_, err = fmt.Fprintf(w, "%d:%s\n", line.Number, line.Text)
+		} else {
+			_, err = fmt.Fprintln(w, line.Text)
+		}
+		written++
+		if canFlush && written%1000 == 0 {
+			flusher.Flush()
+		}
+		return err
+	})
+	if err != nil {
+		// Headers are gone already, all we can do is tell the reader
+		fmt.Fprintf(w, "\n[error reading %s: %v]\n", r.PathValue("name"), err)
+	}
+}
+
+// HandleDownloadLogFile serves the file as stored (gzipped archives stay gzipped).
+// Range requests are supported.
+func (h *Handler) HandleDownloadLogFile(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+	path, err := logfiles.Path(mc.WorkDir, name)
+	if err != nil {
+		http.Error(w, "Log file not found", http.StatusNotFound)
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", mc.ID+"-"+name))
+	if strings.HasSuffix(name, ".gz") {
+		w.Header().Set("Content-Type", "application/gzip")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
@@ -2,7 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"paperMC_backend/internal/minecraft"
 )
 
 type PlayerRequest struct {
@@ -10,10 +13,24 @@ type PlayerRequest struct {
 	Reason   string `json:"reason,omitempty"`
 }
 
+// playerError answers 400 with the server's message when it refused the
+// command ("That player does not exist"), 500 otherwise.
+func playerError(w http.ResponseWriter, err error) {
+	if errors.Is(err, minecraft.ErrCommandRejected) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
 // --- WHITELIST ---
 
 func (h *Handler) HandleGetPlayers(w http.ResponseWriter, r *http.Request) {
-	players, err := h.mc.GetWhiteList()
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	players, err := mc.GetWhiteList()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -23,6 +40,10 @@ func (h *Handler) HandleGetPlayers(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleAddPlayer(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	var req PlayerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -32,8 +53,8 @@ func (h *Handler) HandleAddPlayer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Username required", http.StatusBadRequest)
 		return
 	}
-	if err := h.mc.WhiteListUser(req.Username); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := mc.WhiteListUser(req.Username); err != nil {
+		playerError(w, err)
 		return
 	}
 	// If successful, also remove from rejected list (cleanup)
@@ -44,13 +65,17 @@ func (h *Handler) HandleAddPlayer(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleRemovePlayer(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	username := r.URL.Query().Get("username")
 	if username == "" {
 		http.Error(w, "Username required", http.StatusBadRequest)
 		return
 	}
-	if err := h.mc.RemoveWhitelist(username); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := mc.RemoveWhitelist(username); err != nil {
+		playerError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -60,7 +85,11 @@ func (h *Handler) HandleRemovePlayer(w http.ResponseWriter, r *http.Request) {
 // --- BANNED PLAYERS ---
 
 func (h *Handler) HandleGetBanned(w http.ResponseWriter, r *http.Request) {
-	players, err := h.mc.GetBanned()
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	players, err := mc.GetBanned()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -70,6 +99,10 @@ func (h *Handler) HandleGetBanned(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleBanPlayer(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	var req PlayerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -79,8 +112,8 @@ func (h *Handler) HandleBanPlayer(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Username required", http.StatusBadRequest)
 		return
 	}
-	if err := h.mc.BanUser(req.Username, req.Reason); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := mc.BanUser(req.Username, req.Reason); err != nil {
+		playerError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -88,13 +121,17 @@ func (h *Handler) HandleBanPlayer(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleUnbanPlayer(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	username := r.URL.Query().Get("username")
 	if username == "" {
 		http.Error(w, "Username required", http.StatusBadRequest)
 		return
 	}
-	if err := h.mc.UnbanUser(username); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := mc.UnbanUser(username); err != nil {
+		playerError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
@@ -104,7 +141,11 @@ func (h *Handler) HandleUnbanPlayer(w http.ResponseWriter, r *http.Request) {
 // --- OPS ---
 
 func (h *Handler) HandleGetOps(w http.ResponseWriter, r *http.Request) {
-	players, err := h.mc.GetOps()
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	players, err := mc.GetOps()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -114,6 +155,10 @@ func (h *Handler) HandleGetOps(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) HandleOpPlayer(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
 	var req PlayerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -124,13 +169,13 @@ func (h *Handler) HandleOpPlayer(w http.ResponseWriter, r *http.Request) {
 
 	var err error
 	if action == "remove" {
-		err = h.mc.DeopUser(req.Username)
+		err = mc.DeopUser(req.Username)
 	} else {
-		err = h.mc.OpUser(req.Username)
+		err = mc.OpUser(req.Username)
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		playerError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
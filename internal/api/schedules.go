@@ -0,0 +1,162 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/minecraft"
+	"paperMC_backend/internal/scheduler"
+)
+
+type ScheduleRequest struct {
+	Cron         string `json:"cron"`
+	SkipIfOnline bool   `json:"skip_if_online"`
+	Enabled      *bool  `json:"enabled,omitempty"` // defaults to true
+}
+
+type ScheduleResponse struct {
+	database.RestartSchedule
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+func (h *Handler) HandleGetSchedules(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	schedules, err := h.store.GetRestartSchedules()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	response := make([]ScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		if schedule.InstanceID != mc.ID {
+			continue
+		}
+		response = append(response, ScheduleResponse{
+			RestartSchedule: schedule,
+			NextRun:         scheduler.NextRun(schedule),
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *Handler) HandleCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	schedule, ok := decodeSchedule(w, r)
+	if !ok {
+		return
+	}
+	schedule.InstanceID = mc.ID
+	if err := h.store.CreateRestartSchedule(schedule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.reloadSchedules(w, schedule)
+}
+
+func (h *Handler) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	mc, id, ok := h.scheduleID(w, r)
+	if !ok {
+		return
+	}
+	schedule, ok := decodeSchedule(w, r)
+	if !ok {
+		return
+	}
+	schedule.ID = id
+	schedule.InstanceID = mc.ID
+	if err := h.store.UpdateRestartSchedule(schedule); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Schedule not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.reloadSchedules(w, schedule)
+}
+
+func (h *Handler) HandleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	_, id, ok := h.scheduleID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteRestartSchedule(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Schedule not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := h.scheduler.Reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "Schedule deleted"})
+}
+
+// scheduleID reads ?id= and checks the schedule belongs to the addressed server.
+func (h *Handler) scheduleID(w http.ResponseWriter, r *http.Request) (*minecraft.Server, int, bool) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return nil, 0, false
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Schedule id required", http.StatusBadRequest)
+		return nil, 0, false
+	}
+	schedule, err := h.store.GetRestartSchedule(id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && schedule.InstanceID != mc.ID) {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return nil, 0, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, 0, false
+	}
+	return mc, id, true
+}
+
+func decodeSchedule(w http.ResponseWriter, r *http.Request) (*database.RestartSchedule, bool) {
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	if _, err := scheduler.ParseCron(req.Cron); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	schedule := &database.RestartSchedule{
+		Cron:         req.Cron,
+		SkipIfOnline: req.SkipIfOnline,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}
+	return schedule, true
+}
+
+func (h *Handler) reloadSchedules(w http.ResponseWriter, schedule *database.RestartSchedule) {
+	if err := h.scheduler.Reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ScheduleResponse{
+		RestartSchedule: *schedule,
+		NextRun:         scheduler.NextRun(*schedule),
+	})
+}
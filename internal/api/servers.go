@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/minecraft"
+)
+
+// instance resolves the stored settings of the addressed server.
+func (h *Handler) instance(w http.ResponseWriter, r *http.Request) (database.Instance, bool) {
+	instance, ok := h.servers.Instance(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Server not found", http.StatusNotFound)
+		return database.Instance{}, false
+	}
+	return instance, true
+}
+
+func (h *Handler) HandleListServers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.servers.List())
+}
+
+func (h *Handler) HandleGetServer(w http.ResponseWriter, r *http.Request) {
+	instance, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	mc, _ := h.servers.Get(instance.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(minecraft.InstanceInfo{Instance: instance, Status: mc.GetStatus()})
+}
+
+func (h *Handler) HandleCreateServer(w http.ResponseWriter, r *http.Request) {
+	var instance database.Instance
+	if err := json.NewDecoder(r.Body).Decode(&instance); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	// Defaults for the optional fields
+	if instance.Name == "" {
+		instance.Name = instance.ID
+	}
+	if instance.WorkDir == "" {
+		instance.WorkDir = filepath.Join(h.serversDir, instance.ID)
+	}
+	if instance.JarFile == "" {
+		instance.JarFile = "server.jar"
+	}
+	if instance.RAM == "" {
+		instance.RAM = "2G"
+	}
+	if instance.JVMProfile == "" {
+		instance.JVMProfile = minecraft.DefaultProfile
+	}
+	if instance.ServerArgs == nil {
+		instance.ServerArgs = []string{"nogui"}
+	}
+
+	mc, err := h.servers.Create(instance)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	created, _ := h.servers.Instance(mc.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *Handler) HandleUpdateServer(w http.ResponseWriter, r *http.Request) {
+	instance, ok := h.instance(w, r)
+	if !ok {
+		return
+	}
+	// Decode on top of the current values so partial bodies work
+	if err := json.NewDecoder(r.Body).Decode(&instance); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	instance.ID = r.PathValue("id")
+
+	if err := h.servers.Update(instance); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(instance)
+}
+
+// HandleDeleteServer removes a stopped server. ?files=true also deletes its work dir.
+func (h *Handler) HandleDeleteServer(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	removeFiles := r.URL.Query().Get("files") == "true"
+
+	if err := h.servers.Delete(id, removeFiles); err != nil {
+		if errors.Is(err, minecraft.ErrServerNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	// Its schedules are gone with it
+	h.scheduler.Reload()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(StatusResponse{Status: "Server deleted"})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"paperMC_backend/internal/config"
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
+	"paperMC_backend/internal/minecraft"
+	"paperMC_backend/internal/scheduler"
+	"paperMC_backend/internal/updater"
+)
+
+// newTestMux serves a few per server routes like main does: under their
+// legacy path for the default server and under /api/servers/{id}.
+func newTestMux(t *testing.T) (*http.ServeMux, map[string]string) {
+	t.Helper()
+	store, err := database.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("[TEST] NewSQLiteStore error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	servers := minecraft.NewServerManager(store, java.NewRegistry(nil), minecraft.ServerOptions{})
+	t.Cleanup(servers.Close)
+	dirs := map[string]string{"default": t.TempDir(), "creative": t.TempDir()}
+	seed := database.Instance{ID: "default", Name: "Default", WorkDir: dirs["default"], JarFile: "server.jar", RAM: "1G", Port: 25565, ServerArgs: []string{"nogui"}}
+	if err := servers.Load(seed); err != nil {
+		t.Fatalf("[TEST] Load failed: %v", err)
+	}
+	creative := database.Instance{ID: "creative", Name: "Creative", WorkDir: dirs["creative"], JarFile: "server.jar", RAM: "1G", Port: 25566, ServerArgs: []string{"nogui", "--world-dir", "worlds"}}
+	if _, err := servers.Create(creative); err != nil {
+		t.Fatalf("[TEST] Create failed: %v", err)
+	}
+	for id, dir := range dirs {
+		if err := config.SaveProperties(dir, map[string]string{"motd": id}); err != nil {
+			t.Fatalf("[TEST] SaveProperties failed: %v", err)
+		}
+	}
+
+	h := NewServerHandler(servers, store, scheduler.New(servers, store), java.NewRegistry(nil), t.TempDir(), updater.NewClient(""), updater.ChannelStable)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/servers", h.HandleListServers)
+	mux.HandleFunc("GET /api/servers/{id}", h.HandleGetServer)
+	mux.HandleFunc("DELETE /api/servers/{id}", h.HandleDeleteServer)
+	mux.HandleFunc("GET /config", h.GetConfig)
+	mux.HandleFunc("GET /api/servers/{id}/config", h.GetConfig)
+	mux.HandleFunc("POST /config", h.PostConfig)
+	mux.HandleFunc("POST /api/servers/{id}/config", h.PostConfig)
+	mux.HandleFunc("GET /api/jvm/selection", h.HandleGetLaunchOptions)
+	mux.HandleFunc("GET /api/servers/{id}/jvm/selection", h.HandleGetLaunchOptions)
+	return mux, dirs
+}
+
+func serve(mux *http.ServeMux, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestServerRoutes(t *testing.T) {
+	mux, _ := newTestMux(t)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantBody   string // substring of the answer
+	}{
+		{"Legacy path is the default server", "/config", http.StatusOK, `"motd":"default"`},
+		{"Scoped default", "/api/servers/default/config", http.StatusOK, `"motd":"default"`},
+		{"Scoped other server", "/api/servers/creative/config", http.StatusOK, `"motd":"creative"`},
+		{"Unknown server", "/api/servers/nether/config", http.StatusNotFound, "Server not found"},
+		{"Scoped /api route", "/api/servers/creative/jvm/selection", http.StatusOK, `"args":["nogui","--world-dir","worlds"]`},
+		{"Legacy /api route", "/api/jvm/selection", http.StatusOK, `"args":["nogui"]`},
+		{"Server info", "/api/servers/creative", http.StatusOK, `"name":"Creative"`},
+		{"Server list", "/api/servers", http.StatusOK, `"id":"creative"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, http.MethodGet, tt.target, "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("[TEST] GET %s = %d, want: %d (%s)", tt.target, rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("[TEST] GET %s = %s, want it to contain %s", tt.target, rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServerRoutesWrite(t *testing.T) {
+	mux, dirs := newTestMux(t)
+
+	// A scoped write only touches its own server
+	if rec := serve(mux, http.MethodPost, "/api/servers/creative/config", `{"motd":"changed"}`); rec.Code != http.StatusOK {
+		t.Fatalf("[TEST] POST config = %d, want: %d (%s)", rec.Code, http.StatusOK, rec.Body)
+	}
+	for id, want := range map[string]string{"default": "default", "creative": "changed"} {
+		props, err := config.LoadProperties(dirs[id])
+		if err != nil || props["motd"] != want {
+			t.Errorf("[TEST] motd of %s = %q, %v, want: %q", id, props["motd"], err, want)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{"Unknown server", http.MethodDelete, "/api/servers/nether", http.StatusNotFound},
+		{"Default server", http.MethodDelete, "/api/servers/default", http.StatusConflict},
+		{"Other server", http.MethodDelete, "/api/servers/creative", http.StatusOK},
+		{"Routes of a deleted server", http.MethodGet, "/api/servers/creative/config", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serve(mux, tt.method, tt.target, ""); rec.Code != tt.wantStatus {
+				t.Errorf("[TEST] %s %s = %d, want: %d (%s)", tt.method, tt.target, rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+
+	var list []minecraft.InstanceInfo
+	json.NewDecoder(serve(mux, http.MethodGet, "/api/servers", "").Body).Decode(&list)
+	if len(list) != 1 || list[0].ID != "default" {
+		t.Errorf("[TEST] servers after delete = %+v, want only default", list)
+	}
+}
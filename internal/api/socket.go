@@ -1,8 +1,12 @@
 package api
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+
+	"paperMC_backend/internal/minecraft"
 
 	"github.com/gorilla/websocket"
 )
@@ -15,11 +19,79 @@ var upgrader = websocket.Upgrader{
 
 // WSMessage defines the JSON format for all websocket traffic
 type WSMessage struct {
-	Type string `json:"type"` // "log" or "command"
-	Data string `json:"data"`
+	Type    string `json:"type"` // "log", "command", "error", "gap", "event" (game event), "job" or a lifecycle event ("exit")
+	Data    string `json:"data"`
+	Seq     int64  `json:"seq,omitempty"`    // of a log line, send it back as ?cursor= to resume
+	Stream  string `json:"stream,omitempty"` // origin of a log line: "stdout", "stderr" or "system"
+	Level   string `json:"level,omitempty"`  // of a log line, its fields and colors are in Payload
+	Error   bool   `json:"error,omitempty"`  // log line the console should highlight
+	Payload any    `json:"payload,omitempty"`
+}
+
+func logMessage(line minecraft.LogLine) WSMessage {
+	ws := WSMessage{Type: "log", Data: line.Text, Seq: line.Seq, Stream: line.Stream, Level: line.Level, Error: line.Stream == minecraft.StreamStderr}
+	if line.Record != nil {
+		ws.Payload = line.Record
+	}
+	return ws
+}
+
+// gapMessage tells the client which lines it will never receive.
+func gapMessage(gap minecraft.Message) WSMessage {
+	text := fmt.Sprintf("[System] %d messages skipped, the connection is too slow", gap.Dropped)
+	if gap.FromSeq > 0 {
+		text = fmt.Sprintf("[System] Lines %d to %d were skipped, search them with /api/logs", gap.FromSeq, gap.ToSeq)
+	}
+	return WSMessage{Type: "gap", Data: text, Payload: gap}
+}
+
+// cursorFrom reads the last sequence number a client saw, from ?cursor= or
+// the Last-Event-ID header an EventSource sends when it reconnects.
+func cursorFrom(r *http.Request) (int64, error) {
+	raw := r.URL.Query().Get("cursor")
+	if raw == "" {
+		raw = r.Header.Get("Last-Event-ID")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	cursor, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || cursor < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", raw)
+	}
+	return cursor, nil
+}
+
+// hubMessage converts what the hub delivers to the websocket format.
+func hubMessage(msg minecraft.Message) WSMessage {
+	switch msg.Kind {
+	case minecraft.KindLog:
+		return logMessage(*msg.Log)
+	case minecraft.KindGame:
+		return WSMessage{Type: "event", Data: msg.Game.Type, Payload: msg.Game}
+	case minecraft.KindGap:
+		return gapMessage(msg)
+	case minecraft.KindJob:
+		return WSMessage{Type: "job", Data: msg.Job.Stage, Payload: msg.Job}
+	default:
+		ws := WSMessage{Type: msg.Event.Type, Payload: msg.Event}
+		if msg.Event.Exit != nil {
+			ws.Data = msg.Event.Exit.Reason
+		}
+		return ws
+	}
 }
 
 func (h *Handler) SocketHandler(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	cursor, err := cursorFrom(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	// 1. Upgrade HTTP to websocket
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -28,10 +100,14 @@ func (h *Handler) SocketHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	// 2. REPLAY HISTORY (Immediate Context)
-	history := h.mc.GetHistory()
+	// 2. REPLAY HISTORY (Immediate Context), or what a resuming client missed
+	gap, history, sub := mc.Resume(cursor, minecraft.SubscriberBuffer, minecraft.DropWithGap)
+	defer sub.Close()
+	if gap.Kind == minecraft.KindGap {
+		conn.WriteJSON(gapMessage(gap))
+	}
 	for _, line := range history {
-		if err := conn.WriteJSON(WSMessage{Type: "log", Data: line}); err != nil {
+		if err := conn.WriteJSON(logMessage(line)); err != nil {
 			log.Println("Write error", err)
 		}
 	}
@@ -44,10 +120,14 @@ func (h *Handler) SocketHandler(w http.ResponseWriter, r *http.Request) {
 		defer close(quit) // Ensure we clean up
 		for {
 			select {
-			case msg := <-h.mc.LogChan:
-				err := conn.WriteJSON(WSMessage{Type: "log", Data: msg})
-				if err != nil {
+			case msg, ok := <-sub.C():
+				if !ok {
+					conn.Close() // Unblocks the reader below
+					return
+				}
+				if err := conn.WriteJSON(hubMessage(msg)); err != nil {
 					log.Println("WS Write Error", err)
+					conn.Close()
 					return
 				}
 			case <-r.Context().Done():
@@ -68,7 +148,7 @@ func (h *Handler) SocketHandler(w http.ResponseWriter, r *http.Request) {
 
 		if msg.Type == "command" {
 			// execute the command
-			if err := h.mc.SendCommand(msg.Data); err != nil {
+			if err := mc.SendCommand(msg.Data); err != nil {
 				conn.WriteJSON(WSMessage{Type: "error", Data: err.Error()})
 			}
 		}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"paperMC_backend/internal/updater"
+)
+
+type VersionsResponse struct {
+	Installed *updater.Installed       `json:"installed"`
+	Versions  []updater.VersionRelease `json:"versions"`
+}
+
+type BuildsResponse struct {
+	Version   string             `json:"version"`
+	Installed *updater.Installed `json:"installed"`
+	Channel   updater.Channel    `json:"channel"` // followed by /update, decides "latest"
+	Builds    []updater.Release  `json:"builds"`
+}
+
+// HandleListVersions answers GET /api/updates/versions, the Paper versions
+// newest first with the installed one marked.
+func (h *Handler) HandleListVersions(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	versions, err := h.paper.Versions(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	installed := updater.DetectInstalled(mc.WorkDir, mc.JarFile)
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(VersionsResponse{
+		Installed: installed,
+		Versions:  updater.VersionReleases(versions, installed),
+	})
+}
+
+// HandleListBuilds answers GET /api/updates/versions/{version}/builds with the
+// changelog of every build, newest first.
+// ?channel= only keeps the builds that channel allows, ?limit= the newest N.
+func (h *Handler) HandleListBuilds(w http.ResponseWriter, r *http.Request) {
+	mc, ok := h.server(w, r)
+	if !ok {
+		return
+	}
+	version := r.PathValue("version")
+	query := r.URL.Query()
+
+	// 1. Filters
+	show := updater.ChannelAlpha
+	if raw := query.Get("channel"); raw != "" {
+		var err error
+		if show, err = updater.ParseChannel(raw); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// 2. Every build, "latest" is computed on the channel /update follows
+	builds, err := h.paper.Builds(r.Context(), version, updater.ChannelAlpha)
+	if errors.Is(err, updater.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// 3. Without a build number the installed build is found by its hash
+	installed := updater.DetectInstalled(mc.WorkDir, mc.JarFile)
+	var hash string
+	if installed != nil && installed.Version == version && installed.Build == 0 {
+		hash, _ = updater.GetFileHash(filepath.Join(mc.WorkDir, mc.JarFile))
+	}
+	releases := []updater.Release{}
+	for _, release := range updater.Releases(version, builds, h.channel, installed, hash) {
+		if !show.Allows(release.Channel) {
+			continue
+		}
+		if limit > 0 && len(releases) == limit {
+			break
+		}
+		releases = append(releases, release)
+	}
+
+	w.Header().Set("Content-type", "application/json")
+	json.NewEncoder(w).Encode(BuildsResponse{
+		Version:   version,
+		Installed: installed,
+		Channel:   h.channel,
+		Builds:    releases,
+	})
+}
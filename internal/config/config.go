@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -10,20 +13,70 @@ type Config struct {
 	JarFile string
 	RAM     string
 
+	JavaDirs []string // extra directories scanned for Java runtimes
+
+	// Instances
+	DefaultServer string // ID of the server seeded from WorkDir/JarFile/RAM
+	ServersDir    string // parent of the work dirs of new servers
+	MCPort        int
+
 	DBName    string
 	AdminUser string
 	AdminPass string
+
+	// Supervisor
+	Detach            bool // java outlives the backend and is re-attached on boot
+	RCON              bool // enable rcon with a generated password, for commands with output
+	StartupTimeout    time.Duration
+	StopTimeout       time.Duration
+	TermTimeout       time.Duration
+	AutoRestart       bool
+	RestartMaxRetries int
+	RestartWindow     time.Duration
+	RestartBackoff    time.Duration
+	RestartBackoffMax time.Duration
+
+	// Updates
+	PaperAPI      string // base URL of the PaperMC Fill API
+	UpdateChannel string // default release channel: RECOMMENDED, STABLE, BETA or ALPHA
+
+	// Console log archive
+	LogRetention      time.Duration
+	LogRetentionLines int
 }
 
 func Load() *Config {
 	return &Config{
-		Port:      getEnv("PORT", "8080"),
-		WorkDir:   getEnv("MC_WORKDIR", "./paperMS"),
-		JarFile:   getEnv("JAR_FILE", "server.jar"),
-		RAM:       getEnv("RAM", "8G"),
+		Port:     getEnv("PORT", "8080"),
+		WorkDir:  getEnv("MC_WORKDIR", "./paperMS"),
+		JarFile:  getEnv("JAR_FILE", "server.jar"),
+		RAM:      getEnv("RAM", "8G"),
+		JavaDirs: filepath.SplitList(getEnv("JAVA_DIRS", "")),
+
+		DefaultServer: getEnv("DEFAULT_SERVER", "default"),
+		ServersDir:    getEnv("SERVERS_DIR", "./servers"),
+		MCPort:        getEnvInt("MC_PORT", 0),
+
 		DBName:    getEnv("DBNAME", "paper.db"),
 		AdminUser: getEnv("ADMIN_USER", "admin"),
 		AdminPass: getEnv("ADMIN_PASS", ""),
+
+		Detach:            getEnvBool("MC_DETACH", false),
+		RCON:              getEnvBool("MC_RCON", false),
+		StartupTimeout:    getEnvDuration("STARTUP_TIMEOUT", 5*time.Minute),
+		StopTimeout:       getEnvDuration("STOP_TIMEOUT", time.Minute),
+		TermTimeout:       getEnvDuration("TERM_TIMEOUT", 10*time.Second),
+		AutoRestart:       getEnvBool("AUTO_RESTART", true),
+		RestartMaxRetries: getEnvInt("RESTART_MAX_RETRIES", 5),
+		RestartWindow:     getEnvDuration("RESTART_WINDOW", 10*time.Minute),
+		RestartBackoff:    getEnvDuration("RESTART_BACKOFF", 5*time.Second),
+		RestartBackoffMax: getEnvDuration("RESTART_BACKOFF_MAX", 5*time.Minute),
+
+		PaperAPI:      getEnv("PAPER_API_URL", "https://fill.papermc.io"),
+		UpdateChannel: getEnv("UPDATE_CHANNEL", "STABLE"),
+
+		LogRetention:      getEnvDuration("LOG_RETENTION", 30*24*time.Hour),
+		LogRetentionLines: getEnvInt("LOG_RETENTION_LINES", 1000000),
 	}
 }
 
@@ -33,3 +86,31 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return fallback
+}
+
+// getEnvDuration accepts Go durations ("90s", "5m").
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
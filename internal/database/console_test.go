@@ -0,0 +1,76 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConsoleLogArchive(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("[TEST] NewSQLiteStore error = %v", err)
+	}
+	defer store.Close()
+
+	base := time.Date(2025, time.January, 15, 10, 0, 0, 0, time.UTC)
+	logs := []ConsoleLog{
+		{InstanceID: "default", Seq: 1, Time: base, Level: "INFO", Source: "stdout", Line: "Steve joined the game"},
+		{InstanceID: "default", Seq: 2, Time: base.Add(time.Minute), Level: "WARN", Source: "stdout", Line: "Can't keep up! Is the server overloaded?"},
+		{InstanceID: "default", Seq: 3, Time: base.Add(2 * time.Minute), Level: "INFO", Source: "stdout", Line: "Steve lost connection: Timed out"},
+		{InstanceID: "creative", Seq: 1, Time: base, Level: "INFO", Source: "stdout", Line: "Steve joined the game"},
+	}
+	if err := store.InsertConsoleLogs(logs); err != nil {
+		t.Fatalf("[TEST] InsertConsoleLogs error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query ConsoleLogQuery
+		want  []int64 // seq
+	}{
+		{"Full text", ConsoleLogQuery{InstanceID: "default", Text: "steve", Limit: 10}, []int64{1, 3}},
+		{"Phrase", ConsoleLogQuery{InstanceID: "default", Text: `"lost connection"`, Limit: 10}, []int64{3}},
+		{"Level", ConsoleLogQuery{InstanceID: "default", Levels: []string{"WARN", "ERROR"}, Limit: 10}, []int64{2}},
+		{"Time window", ConsoleLogQuery{InstanceID: "default", Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute), Limit: 10}, []int64{2}},
+		{"Newest within limit", ConsoleLogQuery{InstanceID: "default", Limit: 2}, []int64{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.SearchConsoleLogs(tt.query)
+			if err != nil {
+				t.Fatalf("[TEST] SearchConsoleLogs error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("[TEST] got %d lines, want: %d (%+v)", len(got), len(tt.want), got)
+			}
+			for i, l := range got {
+				if l.Seq != tt.want[i] {
+					t.Errorf("[TEST] line %d seq = %d, want: %d", i, l.Seq, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// Keep one line per instance: default keeps seq 3, creative its only line
+	deleted, err := store.PruneConsoleLogs(time.Time{}, 1)
+	if err != nil || deleted != 2 {
+		t.Fatalf("[TEST] PruneConsoleLogs = %d, %v, want: 2, nil", deleted, err)
+	}
+	if seq, err := store.LastConsoleSeq("default"); err != nil || seq != 3 {
+		t.Errorf("[TEST] LastConsoleSeq = %d, %v, want: 3, nil", seq, err)
+	}
+	if got, _ := store.SearchConsoleLogs(ConsoleLogQuery{Text: "joined", Limit: 10}); len(got) != 1 {
+		t.Errorf("[TEST] FTS index still returns pruned lines: %+v", got)
+	}
+
+	// Sequences never restart, even once every line is pruned
+	if _, err := store.PruneConsoleLogs(base.Add(time.Hour), 0); err != nil {
+		t.Fatalf("[TEST] PruneConsoleLogs error = %v", err)
+	}
+	for id, want := range map[string]int64{"default": 3, "creative": 1, "unknown": 0} {
+		if seq, err := store.LastConsoleSeq(id); err != nil || seq != want {
+			t.Errorf("[TEST] LastConsoleSeq(%s) after full prune = %d, %v, want: %d, nil", id, seq, err, want)
+		}
+	}
+}
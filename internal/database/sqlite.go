@@ -2,6 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -54,7 +57,121 @@ func (s *SQLiteStore) Migrate() error {
 		count INTEGER DEFAULT 1,
 		last_seen DATETIME DEFAULT CURRENT_TIMESTAMP
 	);`
-	_, err := s.db.Exec(queryRejected)
+	if _, err := s.db.Exec(queryRejected); err != nil {
+		return err
+	}
+
+	// 3. Scheduled restarts
+	querySchedules := `CREATE TABLE IF NOT EXISTS restart_schedules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cron TEXT NOT NULL,
+		skip_if_online BOOLEAN NOT NULL DEFAULT 0,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		last_run DATETIME
+	);`
+	if _, err := s.db.Exec(querySchedules); err != nil {
+		return err
+	}
+
+	// 4. JVM flag profiles, flags are stored as a JSON array
+	queryProfiles := `CREATE TABLE IF NOT EXISTS jvm_profiles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT NOT NULL DEFAULT '',
+		flags TEXT NOT NULL DEFAULT '[]'
+	);`
+	if _, err := s.db.Exec(queryProfiles); err != nil {
+		return err
+	}
+
+	// 5. Settings
+	querySettings := `CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`
+	if _, err := s.db.Exec(querySettings); err != nil {
+		return err
+	}
+
+	// 6. Server instances, server_args is a JSON array
+	queryInstances := `CREATE TABLE IF NOT EXISTS instances (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		work_dir TEXT NOT NULL,
+		jar_file TEXT NOT NULL,
+		ram TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		java_path TEXT NOT NULL DEFAULT '',
+		jvm_profile TEXT NOT NULL DEFAULT 'default',
+		server_args TEXT NOT NULL DEFAULT '["nogui"]',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := s.db.Exec(queryInstances); err != nil {
+		return err
+	}
+
+	// 7. Schedules created before instances existed belong to the default server
+	if err := s.addColumn("restart_schedules", "instance_id", "TEXT NOT NULL DEFAULT 'default'"); err != nil {
+		return err
+	}
+
+	// 8. Console log archive, time is in unix milliseconds. The FTS5 index
+	// is an external content table kept in sync by triggers. console_seq is
+	// the highest seq ever archived per instance, pruning doesn't lower it.
+	queryConsole := []string{
+		`CREATE TABLE IF NOT EXISTS console_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			instance_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			time INTEGER NOT NULL,
+			level TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			line TEXT NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_console_logs_instance_time ON console_logs (instance_id, time);`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS console_logs_fts USING fts5(line, content='console_logs', content_rowid='id');`,
+		`CREATE TRIGGER IF NOT EXISTS console_logs_ai AFTER INSERT ON console_logs BEGIN
+			INSERT INTO console_logs_fts (rowid, line) VALUES (new.id, new.line);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS console_logs_ad AFTER DELETE ON console_logs BEGIN
+			INSERT INTO console_logs_fts (console_logs_fts, rowid, line) VALUES ('delete', old.id, old.line);
+		END;`,
+		`CREATE TABLE IF NOT EXISTS console_seq (
+			instance_id TEXT PRIMARY KEY,
+			seq INTEGER NOT NULL
+		);`,
+		// Archives from before console_seq
+		`INSERT OR IGNORE INTO console_seq (instance_id, seq) SELECT instance_id, MAX(seq) FROM console_logs GROUP BY instance_id;`,
+	}
+	for _, query := range queryConsole {
+		if _, err := s.db.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to an existing table, SQLite has no ADD COLUMN IF NOT EXISTS.
+func (s *SQLiteStore) addColumn(table, column, definition string) error {
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition)
 	return err
 }
 
@@ -125,3 +242,430 @@ func (s *SQLiteStore) DeleteRejectedPlayer(username string) error {
 	_, err := s.db.Exec(SQL, username)
 	return err
 }
+
+// --- Server Instances ---
+
+func (s *SQLiteStore) GetInstances() ([]Instance, error) {
+	SQL := `SELECT id, name, work_dir, jar_file, ram, port, java_path, jvm_profile, server_args, created_at
+			FROM instances ORDER BY created_at, id`
+	rows, err := s.db.Query(SQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []Instance{}
+	for rows.Next() {
+		instance, err := scanInstance(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *instance)
+	}
+	return list, rows.Err()
+}
+
+func (s *SQLiteStore) GetInstance(id string) (*Instance, error) {
+	SQL := `SELECT id, name, work_dir, jar_file, ram, port, java_path, jvm_profile, server_args, created_at
+			FROM instances WHERE id = ?`
+	return scanInstance(s.db.QueryRow(SQL, id))
+}
+
+func (s *SQLiteStore) CreateInstance(instance *Instance) error {
+	args, err := json.Marshal(instance.ServerArgs)
+	if err != nil {
+		return err
+	}
+	SQL := `INSERT INTO instances (id, name, work_dir, jar_file, ram, port, java_path, jvm_profile, server_args)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = s.db.Exec(SQL, instance.ID, instance.Name, instance.WorkDir, instance.JarFile, instance.RAM,
+		instance.Port, instance.JavaPath, instance.JVMProfile, string(args))
+	return err
+}
+
+func (s *SQLiteStore) UpdateInstance(instance *Instance) error {
+	args, err := json.Marshal(instance.ServerArgs)
+	if err != nil {
+		return err
+	}
+	SQL := `UPDATE instances SET name = ?, work_dir = ?, jar_file = ?, ram = ?, port = ?,
+				java_path = ?, jvm_profile = ?, server_args = ?
+			WHERE id = ?`
+	res, err := s.db.Exec(SQL, instance.Name, instance.WorkDir, instance.JarFile, instance.RAM, instance.Port,
+		instance.JavaPath, instance.JVMProfile, string(args), instance.ID)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+// DeleteInstance also removes the instance's restart schedules and archived
+// console lines, all or nothing.
+func (s *SQLiteStore) DeleteInstance(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM restart_schedules WHERE instance_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM console_logs WHERE instance_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM console_seq WHERE instance_id = ?`, id); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM instances WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if err := expectRow(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanInstance(row rowScanner) (*Instance, error) {
+	var instance Instance
+	var args, created string
+	err := row.Scan(&instance.ID, &instance.Name, &instance.WorkDir, &instance.JarFile, &instance.RAM,
+		&instance.Port, &instance.JavaPath, &instance.JVMProfile, &args, &created)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(args), &instance.ServerArgs); err != nil {
+		return nil, err
+	}
+	instance.CreatedAt, _ = parseTime(created)
+	return &instance, nil
+}
+
+// --- Scheduled Restarts ---
+
+func (s *SQLiteStore) GetRestartSchedules() ([]RestartSchedule, error) {
+	SQL := `SELECT id, instance_id, cron, skip_if_online, enabled, last_run FROM restart_schedules ORDER BY id`
+	rows, err := s.db.Query(SQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []RestartSchedule{}
+	for rows.Next() {
+		schedule, err := scanRestartSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *schedule)
+	}
+	return list, rows.Err()
+}
+
+func (s *SQLiteStore) GetRestartSchedule(id int) (*RestartSchedule, error) {
+	SQL := `SELECT id, instance_id, cron, skip_if_online, enabled, last_run FROM restart_schedules WHERE id = ?`
+	return scanRestartSchedule(s.db.QueryRow(SQL, id))
+}
+
+func (s *SQLiteStore) CreateRestartSchedule(schedule *RestartSchedule) error {
+	SQL := `INSERT INTO restart_schedules (instance_id, cron, skip_if_online, enabled) VALUES (?, ?, ?, ?)`
+	res, err := s.db.Exec(SQL, schedule.InstanceID, schedule.Cron, schedule.SkipIfOnline, schedule.Enabled)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	schedule.ID = int(id)
+	return nil
+}
+
+func (s *SQLiteStore) UpdateRestartSchedule(schedule *RestartSchedule) error {
+	SQL := `UPDATE restart_schedules SET cron = ?, skip_if_online = ?, enabled = ? WHERE id = ?`
+	res, err := s.db.Exec(SQL, schedule.Cron, schedule.SkipIfOnline, schedule.Enabled, schedule.ID)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLiteStore) DeleteRestartSchedule(id int) error {
+	SQL := `DELETE FROM restart_schedules WHERE id = ?`
+	res, err := s.db.Exec(SQL, id)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLiteStore) SetRestartScheduleLastRun(id int, t time.Time) error {
+	SQL := `UPDATE restart_schedules SET last_run = ? WHERE id = ?`
+	_, err := s.db.Exec(SQL, t.UTC().Format(sqliteTimeLayout), id)
+	return err
+}
+
+// sqliteTimeLayout matches what CURRENT_TIMESTAMP writes.
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// parseTime reads a DATETIME column scanned into a string. The driver hands
+// DATETIME values back as RFC3339, raw text uses the CURRENT_TIMESTAMP layout.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(sqliteTimeLayout, value)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRestartSchedule(row rowScanner) (*RestartSchedule, error) {
+	var schedule RestartSchedule
+	var lastRun sql.NullString
+	if err := row.Scan(&schedule.ID, &schedule.InstanceID, &schedule.Cron, &schedule.SkipIfOnline, &schedule.Enabled, &lastRun); err != nil {
+		return nil, err
+	}
+	if lastRun.Valid {
+		if t, err := parseTime(lastRun.String); err == nil {
+			schedule.LastRun = &t
+		}
+	}
+	return &schedule, nil
+}
+
+// expectRow turns "nothing matched" into sql.ErrNoRows for UPDATE/DELETE.
+func expectRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// --- Console log archive ---
+
+func (s *SQLiteStore) InsertConsoleLogs(logs []ConsoleLog) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO console_logs (instance_id, seq, time, level, source, line) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	highest := make(map[string]int64)
+	for _, l := range logs {
+		if _, err := stmt.Exec(l.InstanceID, l.Seq, l.Time.UnixMilli(), l.Level, l.Source, l.Line); err != nil {
+			return err
+		}
+		highest[l.InstanceID] = max(highest[l.InstanceID], l.Seq)
+	}
+	for id, seq := range highest {
+		SQL := `INSERT INTO console_seq (instance_id, seq) VALUES (?, ?)
+				ON CONFLICT (instance_id) DO UPDATE SET seq = MAX(seq, excluded.seq)`
+		if _, err := tx.Exec(SQL, id, seq); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchConsoleLogs returns the newest matching lines, oldest first.
+func (s *SQLiteStore) SearchConsoleLogs(query ConsoleLogQuery) ([]ConsoleLog, error) {
+	SQL := `SELECT l.id, l.instance_id, l.seq, l.time, l.level, l.source, l.line FROM console_logs l`
+	var where []string
+	var args []any
+	if query.Text != "" {
+		SQL += ` JOIN console_logs_fts f ON f.rowid = l.id`
+		where = append(where, `console_logs_fts MATCH ?`)
+		args = append(args, query.Text)
+	}
+	if query.InstanceID != "" {
+		where = append(where, `l.instance_id = ?`)
+		args = append(args, query.InstanceID)
+	}
+	if len(query.Levels) > 0 {
+		where = append(where, `l.level IN (?`+strings.Repeat(`, ?`, len(query.Levels)-1)+`)`)
+		for _, level := range query.Levels {
+			args = append(args, level)
+		}
+	}
+	if !query.Since.IsZero() {
+		where = append(where, `l.time >= ?`)
+		args = append(args, query.Since.UnixMilli())
+	}
+	if !query.Until.IsZero() {
+		where = append(where, `l.time < ?`)
+		args = append(args, query.Until.UnixMilli())
+	}
+	if len(where) > 0 {
+		SQL += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	SQL += ` ORDER BY l.id DESC LIMIT ?`
+	args = append(args, query.Limit)
+
+	rows, err := s.db.Query(SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []ConsoleLog{}
+	for rows.Next() {
+		var l ConsoleLog
+		var ms int64
+		if err := rows.Scan(&l.ID, &l.InstanceID, &l.Seq, &ms, &l.Level, &l.Source, &l.Line); err != nil {
+			return nil, err
+		}
+		l.Time = time.UnixMilli(ms).UTC()
+		logs = append(logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest first from the query, chronological for the reader
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	return logs, nil
+}
+
+// LastConsoleSeq is the highest seq archived for an instance, pruned lines included.
+func (s *SQLiteStore) LastConsoleSeq(instanceID string) (int64, error) {
+	var seq int64
+	err := s.db.QueryRow(`SELECT seq FROM console_seq WHERE instance_id = ?`, instanceID).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return seq, err
+}
+
+// PruneConsoleLogs deletes the lines older than before and keeps at most
+// keepLines lines per instance (0 = no line limit).
+func (s *SQLiteStore) PruneConsoleLogs(before time.Time, keepLines int) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM console_logs WHERE time < ?`, before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	deleted, _ := res.RowsAffected()
+	if keepLines <= 0 {
+		return deleted, nil
+	}
+
+	SQL := `DELETE FROM console_logs WHERE id IN (
+		SELECT id FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY instance_id ORDER BY id DESC) AS n FROM console_logs
+		) WHERE n > ?
+	)`
+	res, err = s.db.Exec(SQL, keepLines)
+	if err != nil {
+		return deleted, err
+	}
+	n, _ := res.RowsAffected()
+	return deleted + n, nil
+}
+
+// --- JVM Profiles ---
+
+func (s *SQLiteStore) GetJVMProfiles() ([]JVMProfile, error) {
+	SQL := `SELECT id, name, description, flags FROM jvm_profiles ORDER BY name`
+	rows, err := s.db.Query(SQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []JVMProfile{}
+	for rows.Next() {
+		profile, err := scanJVMProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *profile)
+	}
+	return list, rows.Err()
+}
+
+func (s *SQLiteStore) GetJVMProfile(name string) (*JVMProfile, error) {
+	SQL := `SELECT id, name, description, flags FROM jvm_profiles WHERE name = ?`
+	return scanJVMProfile(s.db.QueryRow(SQL, name))
+}
+
+func (s *SQLiteStore) CreateJVMProfile(profile *JVMProfile) error {
+	flags, err := json.Marshal(profile.Flags)
+	if err != nil {
+		return err
+	}
+	SQL := `INSERT INTO jvm_profiles (name, description, flags) VALUES (?, ?, ?)`
+	res, err := s.db.Exec(SQL, profile.Name, profile.Description, string(flags))
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	profile.ID = int(id)
+	return nil
+}
+
+func (s *SQLiteStore) UpdateJVMProfile(profile *JVMProfile) error {
+	flags, err := json.Marshal(profile.Flags)
+	if err != nil {
+		return err
+	}
+	SQL := `UPDATE jvm_profiles SET description = ?, flags = ? WHERE name = ?`
+	res, err := s.db.Exec(SQL, profile.Description, string(flags), profile.Name)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func (s *SQLiteStore) DeleteJVMProfile(name string) error {
+	SQL := `DELETE FROM jvm_profiles WHERE name = ?`
+	res, err := s.db.Exec(SQL, name)
+	if err != nil {
+		return err
+	}
+	return expectRow(res)
+}
+
+func scanJVMProfile(row rowScanner) (*JVMProfile, error) {
+	var profile JVMProfile
+	var flags string
+	if err := row.Scan(&profile.ID, &profile.Name, &profile.Description, &flags); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(flags), &profile.Flags); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// --- Settings ---
+
+// GetSetting returns sql.ErrNoRows if the key was never set.
+func (s *SQLiteStore) GetSetting(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	return value, err
+}
+
+func (s *SQLiteStore) SetSetting(key, value string) error {
+	SQL := `INSERT INTO settings (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value;`
+	_, err := s.db.Exec(SQL, key, value)
+	return err
+}
@@ -17,6 +17,60 @@ type RejectedPlayer struct {
 	LastSeen time.Time `json:"last_seen"`
 }
 
+// Instance is one managed Minecraft server.
+type Instance struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	WorkDir    string    `json:"work_dir"`
+	JarFile    string    `json:"jar_file"`
+	RAM        string    `json:"ram"`
+	Port       int       `json:"port"`
+	JavaPath   string    `json:"java_path"` // empty = java from PATH
+	JVMProfile string    `json:"jvm_profile"`
+	ServerArgs []string  `json:"server_args"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type RestartSchedule struct {
+	ID           int        `json:"id"`
+	InstanceID   string     `json:"instance_id"`
+	Cron         string     `json:"cron"`
+	SkipIfOnline bool       `json:"skip_if_online"`
+	Enabled      bool       `json:"enabled"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+}
+
+// ConsoleLog is one archived console line.
+type ConsoleLog struct {
+	ID         int64     `json:"id"`
+	InstanceID string    `json:"instance_id"`
+	Seq        int64     `json:"seq"`
+	Time       time.Time `json:"time"`
+	Level      string    `json:"level"`  // INFO, WARN, ERROR...
+	Source     string    `json:"source"` // stdout, stderr or system
+	Line       string    `json:"line"`
+}
+
+// ConsoleLogQuery filters SearchConsoleLogs. Zero values mean "no filter".
+type ConsoleLogQuery struct {
+	InstanceID string
+	Text       string   // FTS5 query ("timeout", "\"lost connection\"", "steve OR alex")
+	Levels     []string // any of these levels
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// JVMProfile is a named set of JVM flags. Built-in profiles are defined in
+// code and never stored; custom ones live in the jvm_profiles table.
+type JVMProfile struct {
+	ID          int      `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Flags       []string `json:"flags"`
+	Builtin     bool     `json:"builtin"`
+}
+
 type Store interface {
 	Migrate() error
 	Close() error
@@ -29,4 +83,36 @@ type Store interface {
 	UpsertRejectedPlayer(username string) error
 	GetRejectedPlayers() ([]RejectedPlayer, error)
 	DeleteRejectedPlayer(username string) error
+
+	// Server instances
+	GetInstances() ([]Instance, error)
+	GetInstance(id string) (*Instance, error)
+	CreateInstance(instance *Instance) error
+	UpdateInstance(instance *Instance) error
+	DeleteInstance(id string) error
+
+	// Scheduled restarts
+	GetRestartSchedules() ([]RestartSchedule, error)
+	GetRestartSchedule(id int) (*RestartSchedule, error)
+	CreateRestartSchedule(schedule *RestartSchedule) error
+	UpdateRestartSchedule(schedule *RestartSchedule) error
+	DeleteRestartSchedule(id int) error
+	SetRestartScheduleLastRun(id int, t time.Time) error
+
+	// JVM flag profiles
+	GetJVMProfiles() ([]JVMProfile, error)
+	GetJVMProfile(name string) (*JVMProfile, error)
+	CreateJVMProfile(profile *JVMProfile) error
+	UpdateJVMProfile(profile *JVMProfile) error
+	DeleteJVMProfile(name string) error
+
+	// Console log archive
+	InsertConsoleLogs(logs []ConsoleLog) error
+	SearchConsoleLogs(query ConsoleLogQuery) ([]ConsoleLog, error)
+	LastConsoleSeq(instanceID string) (int64, error)
+	PruneConsoleLogs(before time.Time, keepLines int) (int64, error)
+
+	// Settings (key/value)
+	GetSetting(key string) (string, error)
+	SetSetting(key, value string) error
 }
@@ -0,0 +1,122 @@
+// Package jarfile opens server jars, including the jars Paperclip nests
+// under META-INF/versions/, without loading them in memory.
+package jarfile
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"strings"
+)
+
+// NestedDir is where Paperclip ships the real server jar.
+const NestedDir = "META-INF/versions/"
+
+// Jar is an open jar. Close it when done.
+type Jar struct {
+	*zip.Reader
+	r    io.ReaderAt // the jar's bytes, entries of nested jars are read through it
+	file *os.File    // the jar on disk, or the temp copy of a compressed nested jar
+	temp bool        // file is a temp copy, removed on Close
+}
+
+// Open opens the jar at path.
+func Open(path string) (*Jar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	jar, err := newJar(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return jar, nil
+}
+
+func newJar(file *os.File) (*Jar, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	return &Jar{Reader: zr, r: file, file: file}, nil
+}
+
+// Close closes the jar. A nested jar read in place shares the file of its
+// parent, closing it is a no-op.
+func (j *Jar) Close() error {
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	if j.temp {
+		os.Remove(j.file.Name())
+	}
+	return err
+}
+
+// Find returns the entry called name, nil when there is none.
+func (j *Jar) Find(name string) *zip.File {
+	for _, f := range j.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Nested returns the jars under META-INF/versions/.
+func (j *Jar) Nested() []*zip.File {
+	var nested []*zip.File
+	for _, f := range j.File {
+		if strings.HasPrefix(f.Name, NestedDir) && strings.HasSuffix(f.Name, ".jar") {
+			nested = append(nested, f)
+		}
+	}
+	return nested
+}
+
+// OpenNested opens a jar stored inside j. A stored entry (Paperclip doesn't
+// compress them) is read in place, a compressed one is unpacked to a temp
+// file. Close the nested jar before j.
+func (j *Jar) OpenNested(f *zip.File) (*Jar, error) {
+	if f.Method == zip.Store {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		section := io.NewSectionReader(j.r, offset, int64(f.UncompressedSize64))
+		zr, err := zip.NewReader(section, section.Size())
+		if err != nil {
+			return nil, err
+		}
+		return &Jar{Reader: zr, r: section}, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	temp, err := os.CreateTemp("", "nested-*.jar")
+	if err != nil {
+		return nil, err
+	}
+	jar, err := func() (*Jar, error) {
+		if _, err := io.Copy(temp, rc); err != nil {
+			return nil, err
+		}
+		return newJar(temp)
+	}()
+	if err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return nil, err
+	}
+	jar.temp = true
+	return jar, nil
+}
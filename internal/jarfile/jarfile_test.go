@@ -0,0 +1,101 @@
+package jarfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeJar creates a zip with the given entries, stored or deflated, and
+// returns its bytes.
+func writeJar(t *testing.T, method uint16, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatalf("[TEST] Failed to create %s: %v", name, err)
+		}
+		f.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("[TEST] Failed to close jar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenNested(t *testing.T) {
+	inner := writeJar(t, zip.Deflate, map[string][]byte{"version.json": []byte(`{"id":"1.21.4"}`)})
+
+	tests := []struct {
+		name     string
+		method   uint16
+		wantTemp bool
+	}{
+		{"Stored, read in place", zip.Store, false},
+		{"Compressed, unpacked", zip.Deflate, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "server.jar")
+			outer := writeJar(t, tt.method, map[string][]byte{
+				"META-INF/MANIFEST.MF":                      []byte("Main-Class: io.papermc.paperclip.Main\n"),
+				"META-INF/versions/1.21.4/paper-1.21.4.jar": inner,
+				"META-INF/versions.list":                    []byte("1.21.4\n"),
+			})
+			if err := os.WriteFile(path, outer, 0644); err != nil {
+				t.Fatalf("[TEST] WriteFile failed: %v", err)
+			}
+
+			jar, err := Open(path)
+			if err != nil {
+				t.Fatalf("[TEST] Open failed: %v", err)
+			}
+			defer jar.Close()
+			if jar.Find("META-INF/MANIFEST.MF") == nil || jar.Find("missing") != nil {
+				t.Errorf("[TEST] Find does not match entry names")
+			}
+			nested := jar.Nested()
+			if len(nested) != 1 {
+				t.Fatalf("[TEST] Nested = %d jars, want: 1", len(nested))
+			}
+
+			nestedJar, err := jar.OpenNested(nested[0])
+			if err != nil {
+				t.Fatalf("[TEST] OpenNested failed: %v", err)
+			}
+			if nestedJar.temp != tt.wantTemp {
+				t.Errorf("[TEST] temp = %v, want: %v", nestedJar.temp, tt.wantTemp)
+			}
+			f := nestedJar.Find("version.json")
+			if f == nil {
+				t.Fatalf("[TEST] version.json not found in the nested jar")
+			}
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("[TEST] Open version.json failed: %v", err)
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(data) != `{"id":"1.21.4"}` {
+				t.Errorf("[TEST] version.json = %q, want: %q", data, `{"id":"1.21.4"}`)
+			}
+
+			var tempPath string
+			if nestedJar.temp {
+				tempPath = nestedJar.file.Name()
+			}
+			if err := nestedJar.Close(); err != nil {
+				t.Errorf("[TEST] Close failed: %v", err)
+			}
+			if _, err := os.Stat(tempPath); tempPath != "" && !os.IsNotExist(err) {
+				t.Errorf("[TEST] temp copy %s not removed: %v", tempPath, err)
+			}
+		})
+	}
+}
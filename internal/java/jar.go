@@ -0,0 +1,138 @@
+package java
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"paperMC_backend/internal/jarfile"
+)
+
+// RequiredVersion returns the Java release a server jar needs, 0 if it can't tell.
+//
+// It looks, in order, at:
+//  1. version.json at the root (vanilla/bundler jars), its "java_version" field.
+//  2. version.json inside the nested jars under META-INF/versions/ (Paperclip).
+//  3. The class file version of the manifest's Main-Class.
+func RequiredVersion(jarPath string) (int, error) {
+	jar, err := jarfile.Open(jarPath)
+	if err != nil {
+		return 0, err
+	}
+	defer jar.Close()
+
+	return requiredVersion(jar, true)
+}
+
+func requiredVersion(jar *jarfile.Jar, nested bool) (int, error) {
+	// 1. version.json
+	if f := jar.Find("version.json"); f != nil {
+		if v, err := versionJSON(f); err == nil && v > 0 {
+			return v, nil
+		}
+	}
+
+	// 2. Paperclip ships the real server as META-INF/versions/<id>/<name>.jar
+	if nested {
+		for _, f := range jar.Nested() {
+			inner, err := jar.OpenNested(f)
+			if err != nil {
+				return 0, err
+			}
+			v, err := requiredVersion(inner, false)
+			inner.Close()
+			if err == nil && v > 0 {
+				return v, nil
+			}
+		}
+	}
+
+	// 3. Main-Class bytecode
+	mainClass, err := manifestMainClass(jar)
+	if err != nil || mainClass == "" {
+		return 0, err
+	}
+	f := jar.Find(strings.ReplaceAll(mainClass, ".", "/") + ".class")
+	if f == nil {
+		return 0, nil
+	}
+	classVersion, err := classFileVersion(f)
+	if err != nil {
+		return 0, err
+	}
+	return classVersion - ClassVersionOffset, nil
+}
+
+func versionJSON(f *zip.File) (int, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	var v struct {
+		JavaVersion int `json:"java_version"`
+	}
+	if err := json.NewDecoder(rc).Decode(&v); err != nil {
+		return 0, err
+	}
+	return v.JavaVersion, nil
+}
+
+func manifestMainClass(jar *jarfile.Jar) (string, error) {
+	f := jar.Find("META-INF/MANIFEST.MF")
+	if f == nil {
+		return "", nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Main-Class:"); ok {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// classFileVersion reads the major version from a class file header
+// (magic 0xCAFEBABE, minor u2, major u2).
+func classFileVersion(f *zip.File) (int, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(rc, header); err != nil {
+		return 0, err
+	}
+	if binary.BigEndian.Uint32(header[:4]) != 0xCAFEBABE {
+		return 0, errors.New("not a class file")
+	}
+	return int(binary.BigEndian.Uint16(header[6:8])), nil
+}
+
+// CheckCompatible returns a descriptive error when the runtime is too old for the jar.
+func CheckCompatible(rt Runtime, jarPath string) error {
+	required, err := RequiredVersion(jarPath)
+	if err != nil || required == 0 || rt.Major == 0 {
+		// Unknown on either side, let the JVM decide
+		return nil
+	}
+	if required > rt.Major {
+		return fmt.Errorf("%s needs Java %d (class file version %d) but the selected runtime %s is Java %d (class file version %d)",
+			jarPath, required, required+ClassVersionOffset, rt.Path, rt.Major, rt.ClassVersion())
+	}
+	return nil
+}
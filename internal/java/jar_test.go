@@ -0,0 +1,98 @@
+package java
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMajor(t *testing.T) {
+	tests := map[string]int{
+		"1.8.0_392": 8,
+		"17.0.9":    17,
+		"21":        21,
+		"25-ea":     25,
+	}
+	for version, want := range tests {
+		got, err := ParseMajor(version)
+		if err != nil || got != want {
+			t.Errorf("[TEST] ParseMajor(%q) = %d, %v, want: %d", version, got, err, want)
+		}
+	}
+}
+
+// writeJar creates a zip with the given entries and returns its bytes.
+func writeJar(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("[TEST] Failed to create %s: %v", name, err)
+		}
+		f.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("[TEST] Failed to close jar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRequiredVersion(t *testing.T) {
+	// Class file for Java 17 (major 61)
+	class17 := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 61}
+	nested := writeJar(t, map[string][]byte{"version.json": []byte(`{"id":"1.21.4","java_version":21}`)})
+
+	tests := []struct {
+		name    string
+		entries map[string][]byte
+		want    int
+	}{
+		{
+			name:    "Vanilla version.json",
+			entries: map[string][]byte{"version.json": []byte(`{"java_version":17}`)},
+			want:    17,
+		},
+		{
+			name: "Paperclip nested jar",
+			entries: map[string][]byte{
+				"META-INF/MANIFEST.MF":                      []byte("Main-Class: io.papermc.paperclip.Main\n"),
+				"io/papermc/paperclip/Main.class":           class17,
+				"META-INF/versions/1.21.4/paper-1.21.4.jar": nested,
+			},
+			want: 21,
+		},
+		{
+			name: "Main-Class bytecode",
+			entries: map[string][]byte{
+				"META-INF/MANIFEST.MF":   []byte("Manifest-Version: 1.0\nMain-Class: com.example.Main\n"),
+				"com/example/Main.class": class17,
+			},
+			want: 17,
+		},
+		{
+			name:    "Unknown",
+			entries: map[string][]byte{"README": []byte("hi")},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "server.jar")
+			if err := os.WriteFile(path, writeJar(t, tt.entries), 0644); err != nil {
+				t.Fatalf("[TEST] Failed to create fixture: %v", err)
+			}
+			got, err := RequiredVersion(path)
+			if err != nil {
+				t.Fatalf("[TEST] RequiredVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("[TEST] RequiredVersion() = %d, want: %d", got, tt.want)
+			}
+		})
+	}
+}
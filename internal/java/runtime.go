@@ -0,0 +1,227 @@
+// Package java discovers installed Java runtimes and checks which Java
+// version a server jar needs.
+//
+// Runtimes are found in JAVA_HOME, the PATH, the usual install locations
+// (/usr/lib/jvm, /usr/java, /opt/java, macOS JavaVirtualMachines) and any
+// configured directory. Each candidate is probed with
+// `java -XshowSettings:properties -version` to record its version and vendor.
+package java
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClassVersionOffset converts between Java releases and class file major versions (Java 21 = 65).
+const ClassVersionOffset = 44
+
+type Runtime struct {
+	Path    string `json:"path"` // java executable
+	Home    string `json:"home"`
+	Version string `json:"version"` // e.g. "21.0.2"
+	Major   int    `json:"major"`   // e.g. 21
+	Vendor  string `json:"vendor"`
+}
+
+// ClassVersion is the highest class file major version this runtime can load.
+func (r Runtime) ClassVersion() int {
+	return r.Major + ClassVersionOffset
+}
+
+// DefaultDirs are scanned in addition to JAVA_HOME, the PATH and configured dirs.
+var DefaultDirs = []string{
+	"/usr/lib/jvm",
+	"/usr/java",
+	"/opt/java",
+	"/Library/Java/JavaVirtualMachines",
+}
+
+const probeTimeout = 10 * time.Second
+
+// Probe runs the given java executable and records its version and vendor.
+func Probe(javaBin string) (Runtime, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	// The properties and the version banner both go to stderr
+	out, err := exec.CommandContext(ctx, javaBin, "-XshowSettings:properties", "-version").CombinedOutput()
+	if err != nil {
+		return Runtime{}, fmt.Errorf("failed to run %s: %w", javaBin, err)
+	}
+
+	rt := Runtime{Path: javaBin}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "java.version":
+			rt.Version = value
+		case "java.vendor":
+			rt.Vendor = value
+		case "java.home":
+			rt.Home = value
+		}
+	}
+
+	if rt.Version == "" {
+		return Runtime{}, fmt.Errorf("could not read the version of %s", javaBin)
+	}
+	rt.Major, err = ParseMajor(rt.Version)
+	if err != nil {
+		return Runtime{}, err
+	}
+	return rt, nil
+}
+
+// ParseMajor turns "1.8.0_392", "17.0.9" or "21" into the feature release number.
+func ParseMajor(version string) (int, error) {
+	version = strings.TrimPrefix(version, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		end = len(version)
+	}
+	major, err := strconv.Atoi(version[:end])
+	if err != nil {
+		return 0, fmt.Errorf("invalid java version %q", version)
+	}
+	return major, nil
+}
+
+// Registry keeps the result of the last scan.
+type Registry struct {
+	dirs []string
+
+	mu       sync.Mutex
+	runtimes []Runtime
+}
+
+func NewRegistry(extraDirs []string) *Registry {
+	return &Registry{dirs: append(append([]string{}, DefaultDirs...), extraDirs...)}
+}
+
+// Scan probes every candidate and replaces the known runtimes.
+func (r *Registry) Scan() []Runtime {
+	found := []Runtime{}
+	seen := map[string]bool{}
+	for _, bin := range r.candidates() {
+		resolved, err := filepath.EvalSymlinks(bin)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+
+		rt, err := Probe(bin)
+		if err != nil {
+			continue
+		}
+		found = append(found, rt)
+	}
+
+	// Newest first
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Major > found[j].Major })
+
+	r.mu.Lock()
+	r.runtimes = found
+	r.mu.Unlock()
+	return found
+}
+
+func (r *Registry) List() []Runtime {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Runtime{}, r.runtimes...)
+}
+
+// Find returns a known runtime by executable path or by home directory.
+func (r *Registry) Find(path string) (Runtime, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.runtimes {
+		if rt.Path == path || rt.Home == path {
+			return rt, true
+		}
+	}
+	return Runtime{}, false
+}
+
+// Resolve returns the runtime for path, probing it if it was not scanned.
+// An empty path means "java from the PATH".
+func (r *Registry) Resolve(path string) (Runtime, error) {
+	if path == "" {
+		bin, err := exec.LookPath("java")
+		if err != nil {
+			return Runtime{}, errors.New("no java found in PATH")
+		}
+		path = bin
+	}
+	if rt, ok := r.Find(path); ok {
+		return rt, nil
+	}
+	// A JDK home works as well as the executable
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = javaBin(path)
+	}
+	return Probe(path)
+}
+
+func (r *Registry) candidates() []string {
+	var bins []string
+
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		bins = append(bins, javaBin(home))
+	}
+	if bin, err := exec.LookPath("java"); err == nil {
+		bins = append(bins, bin)
+	}
+
+	for _, dir := range r.dirs {
+		// The dir itself may be a JDK home
+		if isFile(javaBin(dir)) {
+			bins = append(bins, javaBin(dir))
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			home := filepath.Join(dir, entry.Name())
+			// macOS bundles: <name>.jdk/Contents/Home
+			if macHome := filepath.Join(home, "Contents", "Home"); isFile(javaBin(macHome)) {
+				home = macHome
+			}
+			if isFile(javaBin(home)) {
+				bins = append(bins, javaBin(home))
+			}
+		}
+	}
+	return bins
+}
+
+func javaBin(home string) string {
+	name := "java"
+	if runtime.GOOS == "windows" {
+		name = "java.exe"
+	}
+	return filepath.Join(home, "bin", name)
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
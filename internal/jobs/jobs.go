@@ -0,0 +1,216 @@
+// Package jobs runs long operations (updates) in the background. A job has an
+// ID to poll, reports its stage and progress to a callback and can be
+// cancelled until it commits to something that must not be interrupted.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// State of a job
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+var (
+	ErrNotFound       = errors.New("job not found")
+	ErrNotCancellable = errors.New("job can no longer be cancelled")
+)
+
+// progressInterval throttles progress notifications, a download reports every write
+const progressInterval = 500 * time.Millisecond
+
+// Finished jobs are kept this long for polling
+const keepFinished = time.Hour
+
+type Progress struct {
+	Bytes int64 `json:"bytes"`
+	Total int64 `json:"total"` // 0 when unknown
+}
+
+// Job is a snapshot, what GET /api/jobs/{id} answers.
+type Job struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"` // "update"
+	Server      string    `json:"server"`
+	State       string    `json:"state"`
+	Stage       string    `json:"stage"`
+	Progress    *Progress `json:"progress,omitempty"`
+	Cancellable bool      `json:"cancellable"`
+	Result      string    `json:"result,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Notify receives a snapshot after every change.
+type Notify func(Job)
+
+type Manager struct {
+	mu   sync.Mutex
+	jobs map[string]*Handle
+}
+
+func NewManager() *Manager {
+	return &Manager{jobs: make(map[string]*Handle)}
+}
+
+// Handle is how a running job reports itself.
+type Handle struct {
+	m            *Manager
+	ctx          context.Context
+	cancel       context.CancelFunc
+	notify       Notify
+	job          Job       // guarded by m.mu
+	lastProgress time.Time // guarded by m.mu
+}
+
+// Start runs fn in a goroutine and returns the job as it starts.
+// fn's result is the job's Result, a context error marks it cancelled.
+func (m *Manager) Start(kind, server string, notify Notify, fn func(ctx context.Context, h *Handle) (string, error)) Job {
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	h := &Handle{
+		m:      m,
+		ctx:    ctx,
+		cancel: cancel,
+		notify: notify,
+		job: Job{
+			ID:          newID(),
+			Kind:        kind,
+			Server:      server,
+			State:       StateRunning,
+			Stage:       "queued",
+			Cancellable: true,
+			Created:     now,
+			Updated:     now,
+		},
+	}
+
+	m.mu.Lock()
+	m.prune(now)
+	m.jobs[h.job.ID] = h
+	job := h.job
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		result, err := fn(ctx, h)
+		h.finish(result, err)
+	}()
+	return job
+}
+
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return h.job, true
+}
+
+// Cancel stops a job that has not committed yet. The job ends as cancelled
+// once its function returns.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if !h.job.Cancellable {
+		return h.job, ErrNotCancellable
+	}
+	h.cancel()
+	return h.job, nil
+}
+
+// prune forgets jobs finished long ago, must be called with m.mu held.
+func (m *Manager) prune(now time.Time) {
+	for id, h := range m.jobs {
+		if h.job.State != StateRunning && now.Sub(h.job.Updated) > keepFinished {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// update changes the job under the lock and notifies the snapshot.
+func (h *Handle) update(change func(*Job)) {
+	h.m.mu.Lock()
+	change(&h.job)
+	h.job.Updated = time.Now()
+	job := h.job
+	h.m.mu.Unlock()
+	if h.notify != nil {
+		h.notify(job)
+	}
+}
+
+// Stage moves the job to a new stage and clears its progress.
+func (h *Handle) Stage(stage string) {
+	h.update(func(j *Job) {
+		j.Stage = stage
+		j.Progress = nil
+	})
+}
+
+// Progress reports bytes done out of total, at most every progressInterval
+// except for the last one.
+func (h *Handle) Progress(bytes, total int64) {
+	h.m.mu.Lock()
+	now := time.Now()
+	if bytes != total && now.Sub(h.lastProgress) < progressInterval {
+		h.m.mu.Unlock()
+		return
+	}
+	h.lastProgress = now
+	h.m.mu.Unlock()
+
+	h.update(func(j *Job) {
+		j.Progress = &Progress{Bytes: bytes, Total: total}
+	})
+}
+
+// Commit is the point of no return: the job can't be cancelled after it.
+// It returns the context error if the job was cancelled before.
+func (h *Handle) Commit() error {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	if err := h.ctx.Err(); err != nil {
+		return err
+	}
+	h.job.Cancellable = false
+	return nil
+}
+
+func (h *Handle) finish(result string, err error) {
+	h.update(func(j *Job) {
+		j.Cancellable = false
+		switch {
+		case err == nil:
+			j.State = StateSucceeded
+			j.Result = result
+		case errors.Is(err, context.Canceled):
+			j.State = StateCancelled
+		default:
+			j.State = StateFailed
+			j.Error = err.Error()
+		}
+	})
+}
+
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,129 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitState polls until the job leaves StateRunning.
+func waitState(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if job, _ := m.Get(id); job.State != StateRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("[TEST] job %s still running", id)
+	return Job{}
+}
+
+func TestJobStates(t *testing.T) {
+	tests := []struct {
+		name      string
+		fn        func(ctx context.Context, h *Handle) (string, error)
+		wantState string
+		wantError string
+	}{
+		{"Succeeded", func(ctx context.Context, h *Handle) (string, error) {
+			return "done", nil
+		}, StateSucceeded, ""},
+		{"Failed", func(ctx context.Context, h *Handle) (string, error) {
+			return "", errors.New("boom")
+		}, StateFailed, "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			job := m.Start("update", "default", nil, tt.fn)
+			if job.ID == "" || job.State != StateRunning || !job.Cancellable {
+				t.Fatalf("[TEST] unexpected new job %+v", job)
+			}
+			job = waitState(t, m, job.ID)
+			if job.State != tt.wantState || job.Error != tt.wantError || job.Cancellable {
+				t.Errorf("[TEST] job = %+v, want state %s and error %q", job, tt.wantState, tt.wantError)
+			}
+		})
+	}
+	if _, ok := NewManager().Get("missing"); ok {
+		t.Errorf("[TEST] Get found a missing job")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	m := NewManager()
+	committed := make(chan struct{})
+	release := make(chan struct{})
+
+	// 1. A job waiting before its commit point ends as cancelled
+	job := m.Start("update", "default", nil, func(ctx context.Context, h *Handle) (string, error) {
+		<-ctx.Done()
+		return "", h.Commit()
+	})
+	if _, err := m.Cancel(job.ID); err != nil {
+		t.Fatalf("[TEST] Cancel failed: %v", err)
+	}
+	if job = waitState(t, m, job.ID); job.State != StateCancelled {
+		t.Errorf("[TEST] state = %s, want: %s", job.State, StateCancelled)
+	}
+
+	// 2. Past Commit, the job refuses
+	job = m.Start("update", "default", nil, func(ctx context.Context, h *Handle) (string, error) {
+		if err := h.Commit(); err != nil {
+			return "", err
+		}
+		close(committed)
+		<-release
+		return "ok", nil
+	})
+	<-committed
+	if _, err := m.Cancel(job.ID); !errors.Is(err, ErrNotCancellable) {
+		t.Errorf("[TEST] Cancel err = %v, want: ErrNotCancellable", err)
+	}
+	close(release)
+	if job = waitState(t, m, job.ID); job.State != StateSucceeded {
+		t.Errorf("[TEST] state = %s, want: %s", job.State, StateSucceeded)
+	}
+
+	if _, err := m.Cancel("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("[TEST] Cancel err = %v, want: ErrNotFound", err)
+	}
+}
+
+func TestProgressNotify(t *testing.T) {
+	m := NewManager()
+	var mu sync.Mutex
+	var seen []Job
+	notify := func(job Job) {
+		mu.Lock()
+		seen = append(seen, job)
+		mu.Unlock()
+	}
+	job := m.Start("update", "default", notify, func(ctx context.Context, h *Handle) (string, error) {
+		h.Stage("downloading")
+		for i := int64(1); i <= 100; i++ {
+			h.Progress(i, 100) // throttled, only the first and the last get through
+		}
+		return "ok", nil
+	})
+	waitState(t, m, job.ID)
+
+	mu.Lock()
+	defer mu.Unlock()
+	var progress []int64
+	for _, j := range seen {
+		if j.Progress != nil && j.State == StateRunning {
+			progress = append(progress, j.Progress.Bytes)
+		}
+	}
+	if len(progress) != 2 || progress[0] != 1 || progress[1] != 100 {
+		t.Errorf("[TEST] progress notified %v, want: [1 100]", progress)
+	}
+	if last := seen[len(seen)-1]; last.State != StateSucceeded || last.Result != "ok" {
+		t.Errorf("[TEST] last notification %+v", last)
+	}
+}
@@ -0,0 +1,177 @@
+// Package logfiles reads the log files Paper writes on its own:
+// logs/latest.log and the gzipped daily archives (logs/2025-01-15-1.log.gz).
+package logfiles
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir is where Paper writes its logs, relative to the server work dir.
+const Dir = "logs"
+
+var ErrNotFound = errors.New("log file not found")
+
+type File struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"` // on disk, compressed for .gz files
+	Modified   time.Time `json:"modified"`
+	Compressed bool      `json:"compressed"`
+}
+
+func isLogFile(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")
+}
+
+// List returns the log files of a server, newest first.
+func List(workDir string) ([]File, error) {
+	entries, err := os.ReadDir(filepath.Join(workDir, Dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return []File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := []File{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isLogFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, File{
+			Name:       entry.Name(),
+			Size:       info.Size(),
+			Modified:   info.ModTime(),
+			Compressed: strings.HasSuffix(entry.Name(), ".gz"),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Modified.After(files[j].Modified)
+	})
+	return files, nil
+}
+
+// Path resolves a file name from List, anything else is ErrNotFound.
+func Path(workDir, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") || !isLogFile(name) {
+		return "", ErrNotFound
+	}
+	path := filepath.Join(workDir, Dir, name)
+	// Lstat, a symlink could point anywhere on the host
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// Open returns the text of a log file, decompressed if needed.
+func Open(workDir, name string) (io.ReadCloser, error) {
+	path, err := Path(workDir, name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// Filter selects lines, like grep on a line range.
+type Filter struct {
+	Pattern *regexp.Regexp // nil matches every line
+	Invert  bool           // keep the lines that don't match (grep -v)
+	From    int            // first line number, 1-based, 0 = from the start
+	To      int            // last line number, 0 = to the end
+	Tail    int            // only the last Tail selected lines, 0 = all
+	Limit   int            // stop after Limit lines, 0 = no limit
+}
+
+// Line is a selected line with its number in the file.
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Scan reads r and calls emit for every line the filter selects, in order.
+func Scan(r io.Reader, filter Filter, emit func(Line) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // stack traces can be long
+
+	var tail []Line
+	emitted := 0
+	for n := 1; scanner.Scan(); n++ {
+		if filter.From > 0 && n < filter.From {
+			continue
+		}
+		if filter.To > 0 && n > filter.To {
+			break
+		}
+		text := scanner.Text()
+		if filter.Pattern != nil && filter.Pattern.MatchString(text) == filter.Invert {
+			continue
+		}
+
+		line := Line{Number: n, Text: text}
+		if filter.Tail > 0 {
+			// Ring of the last Tail lines, emitted at the end
+			if len(tail) == filter.Tail {
+				tail = tail[1:]
+			}
+			tail = append(tail, line)
+			continue
+		}
+		if err := emit(line); err != nil {
+			return err
+		}
+		emitted++
+		if filter.Limit > 0 && emitted >= filter.Limit {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for i, line := range tail {
+		if filter.Limit > 0 && i >= filter.Limit {
+			break
+		}
+		if err := emit(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package logfiles
+
+import (
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `[10:00:00] [Server thread/INFO]: Starting minecraft server
+[10:00:01] [Server thread/WARN]: Can't keep up!
+[10:00:02] [Server thread/INFO]: Steve joined the game
+[10:00:03] [Server thread/ERROR]: Error occurred while enabling Foo
+[10:00:04] [Server thread/INFO]: Steve left the game
+`
+
+// writeLogs creates logs/latest.log and an older gzipped archive.
+func writeLogs(t *testing.T) string {
+	t.Helper()
+	workDir := t.TempDir()
+	dir := filepath.Join(workDir, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(dir, "2025-01-15-1.log.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(sample))
+	gz.Close()
+	f.Close()
+	old := time.Now().Add(-24 * time.Hour)
+	os.Chtimes(archive, old, old)
+
+	if err := os.WriteFile(filepath.Join(dir, "latest.log"), []byte("latest\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a log"), 0644)
+	return workDir
+}
+
+func TestList(t *testing.T) {
+	workDir := writeLogs(t)
+
+	files, err := List(workDir)
+	if err != nil {
+		t.Fatalf("[TEST] List failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "latest.log" || files[1].Name != "2025-01-15-1.log.gz" {
+		t.Fatalf("[TEST] List = %+v, want: latest.log then the archive", files)
+	}
+	if !files[1].Compressed || files[0].Compressed {
+		t.Errorf("[TEST] wrong Compressed flags: %+v", files)
+	}
+
+	if files, err := List(t.TempDir()); err != nil || len(files) != 0 {
+		t.Errorf("[TEST] List without a logs dir = %v, %v, want: empty", files, err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	workDir := writeLogs(t)
+
+	for _, name := range []string{"../latest.log", "notes.txt", "missing.log", ".hidden.log", ""} {
+		if _, err := Path(workDir, name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("[TEST] Path(%q) error = %v, want: ErrNotFound", name, err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	workDir := writeLogs(t)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int // selected line numbers
+	}{
+		{"Everything", Filter{}, []int{1, 2, 3, 4, 5}},
+		{"Grep", Filter{Pattern: regexp.MustCompile(`Steve`)}, []int{3, 5}},
+		{"Invert", Filter{Pattern: regexp.MustCompile(`INFO`), Invert: true}, []int{2, 4}},
+		{"Range", Filter{From: 2, To: 4}, []int{2, 3, 4}},
+		{"Tail", Filter{Tail: 2}, []int{4, 5}},
+		{"Limit", Filter{Limit: 2}, []int{1, 2}},
+		{"Grep in range", Filter{Pattern: regexp.MustCompile(`INFO`), From: 2}, []int{3, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := Open(workDir, "2025-01-15-1.log.gz")
+			if err != nil {
+				t.Fatalf("[TEST] Open failed: %v", err)
+			}
+			defer file.Close()
+
+			var got []int
+			err = Scan(file, tt.filter, func(line Line) error {
+				if !strings.HasPrefix(line.Text, "[10:00:0") {
+					t.Errorf("[TEST] line %d not decompressed: %q", line.Number, line.Text)
+				}
+				got = append(got, line.Number)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("[TEST] Scan failed: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("[TEST] got lines %v, want: %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("[TEST] got lines %v, want: %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Package logparse turns Paper console lines into typed game events.
+//
+// It understands the console format ("[12:34:56 INFO]: ...") as well as the
+// logs/latest.log format ("[12:34:56] [Server thread/INFO]: ...").
+package logparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types
+const (
+	TypeJoin            = "join"
+	TypeLeave           = "leave"
+	TypeChat            = "chat"
+	TypeDeath           = "death"
+	TypeAdvancement     = "advancement"
+	TypeCommand         = "command"
+	TypeWhitelistReject = "whitelist_reject"
+	TypeLag             = "lag"
+	TypeDone            = "done"
+	TypePluginError     = "plugin_error"
+)
+
+// Event is something that happened in the game, parsed from one log line.
+type Event struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	Player      string    `json:"player,omitempty"`
+	Message     string    `json:"message,omitempty"` // chat text, death message, advancement title or command
+	Reason      string    `json:"reason,omitempty"`  // why a player left or was rejected
+	Address     string    `json:"address,omitempty"` // address of a rejected connection
+	Plugin      string    `json:"plugin,omitempty"`
+	Action      string    `json:"action,omitempty"` // failed plugin step: "enable", "disable" or "load"
+	BehindMs    int       `json:"behind_ms,omitempty"`
+	BehindTicks int       `json:"behind_ticks,omitempty"`
+	BootSeconds float64   `json:"boot_seconds,omitempty"`
+	Line        string    `json:"line"`
+}
+
+// Player names are up to 16 word characters, Floodgate prefixes Bedrock players with a dot
+const name = `\.?[A-Za-z0-9_]{1,16}`
+
+var (
+	ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	joinRegex        = regexp.MustCompile(`^(` + name + `)(?: \(formerly known as ` + name + `\))? joined the game$`)
+	leftRegex        = regexp.MustCompile(`^(` + name + `) left the game$`)
+	lostRegex        = regexp.MustCompile(`^(.+?)(?: \((/[^)]*)\))? lost connection: (.+)$`)
+	disconnectRegex  = regexp.MustCompile(`^Disconnecting (.+?) \((/[^)]*)\): (.+)$`)
+	profileNameRegex = regexp.MustCompile(`name=(` + name + `)`)
+	chatRegex        = regexp.MustCompile(`^(?:\[Not Secure\] )?<(` + name + `)> (.*)$`)
+	advancementRegex = regexp.MustCompile(`^(` + name + `) has (?:made the advancement|completed the challenge|reached the goal) \[(.+)\]$`)
+	commandRegex     = regexp.MustCompile(`^(` + name + `) issued server command: (.+)$`)
+	lagRegex         = regexp.MustCompile(`^Can't keep up! Is the server overloaded\? Running (\d+)ms or (\d+) ticks behind`)
+	doneRegex        = regexp.MustCompile(`^Done \(([0-9.,]+)s\)! For help, type "help"`)
+	pluginRegex      = regexp.MustCompile(`^Error occurred while (enabling|disabling) (.+?)(?: \(Is it up to date\?\))?$`)
+	pluginLoadRegex  = regexp.MustCompile(`^Could not load '(?:.*/)?([^/']+)' in (?:folder|directory) '.*'`)
+	deathRegex       = regexp.MustCompile(`^(` + name + `) (` + strings.Join(deathPhrases, "|") + `)\b`)
+)
+
+// deathPhrases are the vanilla death messages, after the player name.
+var deathPhrases = []string{
+	`was slain by`, `was shot by`, `was sniped by`, `was fireballed by`, `was pummeled by`,
+	`was spitballed by`, `was killed`, `was blown up by`, `blew up`, `was squashed`,
+	`was squished`, `was impaled`, `was skewered`, `was stung to death`, `was poked to death`,
+	`was pricked to death`, `was struck by lightning`, `was frozen to death`, `froze to death`,
+	`was obliterated by`, `was burnt to a crisp`, `was roasted`, `burned to death`,
+	`went up in flames`, `went off with a bang`, `walked into fire`, `walked into a cactus`,
+	`walked into the danger zone`, `tried to swim in lava`, `discovered the floor was lava`,
+	`drowned`, `suffocated in a wall`, `was smashed by`, `experienced kinetic energy`,
+	`hit the ground too hard`, `fell from a high place`, `fell off`, `fell out of the world`,
+	`fell too far`, `fell while climbing`, `was doomed to fall`, `didn't want to live`,
+	`left the confines of this world`, `starved to death`, `withered away`, `died`,
+}
+
+// Parser keeps the little state needed across lines, like the reason of a
+// disconnect that Paper prints before "left the game".
+type Parser struct {
+	// Now gives the date of the parsed lines, which only carry a time of day
+	Now func() time.Time
+
+	lostReasons map[string]string
+}
+
+func NewParser() *Parser {
+	return &Parser{Now: time.Now, lostReasons: make(map[string]string)}
+}
+
+// Parse returns the event described by a console line, if any.
+func (p *Parser) Parse(line string) (Event, bool) {
+	clean := strings.TrimSpace(ansiRegex.ReplaceAllString(line, ""))
+	ev := Event{Line: clean}
+
+	// 1. Split "[12:34:56 INFO]: " from the message
+	now := p.Now()
+	rec := ParseLine(clean, now)
+	message := rec.Message
+	if rec.Logger != "" {
+		// Events come from the server itself, "[Essentials] Steve joined the game" is none
+		message = "[" + rec.Logger + "] " + message
+	}
+	ev.Time = rec.Time
+	if ev.Time.IsZero() {
+		ev.Time = now
+	}
+
+	// 2. Match the message
+	switch {
+	case doneRegex.MatchString(message):
+		m := doneRegex.FindStringSubmatch(message)
+		ev.Type = TypeDone
+		ev.BootSeconds, _ = strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64)
+
+	case joinRegex.MatchString(message):
+		ev.Type = TypeJoin
+		ev.Player = joinRegex.FindStringSubmatch(message)[1]
+
+	case leftRegex.MatchString(message):
+		ev.Type = TypeLeave
+		ev.Player = leftRegex.FindStringSubmatch(message)[1]
+		ev.Reason = p.lostReasons[ev.Player]
+		delete(p.lostReasons, ev.Player)
+
+	case lostRegex.MatchString(message):
+		m := lostRegex.FindStringSubmatch(message)
+		if !isWhitelistReason(m[3]) {
+			// "left the game" follows, it carries the event
+			p.lostReasons[playerName(m[1])] = m[3]
+			return Event{}, false
+		}
+		ev.Type = TypeWhitelistReject
+		ev.Player, ev.Address, ev.Reason = playerName(m[1]), strings.TrimPrefix(m[2], "/"), m[3]
+
+	case disconnectRegex.MatchString(message):
+		m := disconnectRegex.FindStringSubmatch(message)
+		if !isWhitelistReason(m[3]) {
+			return Event{}, false
+		}
+		ev.Type = TypeWhitelistReject
+		ev.Player, ev.Address, ev.Reason = playerName(m[1]), strings.TrimPrefix(m[2], "/"), m[3]
+
+	case chatRegex.MatchString(message):
+		m := chatRegex.FindStringSubmatch(message)
+		ev.Type = TypeChat
+		ev.Player, ev.Message = m[1], m[2]
+
+	case advancementRegex.MatchString(message):
+		m := advancementRegex.FindStringSubmatch(message)
+		ev.Type = TypeAdvancement
+		ev.Player, ev.Message = m[1], m[2]
+
+	case commandRegex.MatchString(message):
+		m := commandRegex.FindStringSubmatch(message)
+		ev.Type = TypeCommand
+		ev.Player, ev.Message = m[1], m[2]
+
+	case lagRegex.MatchString(message):
+		m := lagRegex.FindStringSubmatch(message)
+		ev.Type = TypeLag
+		ev.BehindMs, _ = strconv.Atoi(m[1])
+		ev.BehindTicks, _ = strconv.Atoi(m[2])
+
+	case pluginRegex.MatchString(message):
+		m := pluginRegex.FindStringSubmatch(message)
+		ev.Type = TypePluginError
+		ev.Action = strings.TrimSuffix(m[1], "ing") + "e" // "enabling" -> "enable"
+		ev.Plugin = pluginName(m[2])
+
+	case pluginLoadRegex.MatchString(message):
+		ev.Type = TypePluginError
+		ev.Action = "load"
+		ev.Plugin = pluginLoadRegex.FindStringSubmatch(message)[1]
+
+	case deathRegex.MatchString(message):
+		ev.Type = TypeDeath
+		ev.Player = deathRegex.FindStringSubmatch(message)[1]
+		ev.Message = message
+
+	default:
+		return Event{}, false
+	}
+	return ev, true
+}
+
+// playerName returns the name of a player, some versions print the whole
+// GameProfile ("com.mojang.authlib.GameProfile@1a2b[id=...,name=Steve,...]").
+func playerName(raw string) string {
+	if profile := profileNameRegex.FindStringSubmatch(raw); profile != nil {
+		return profile[1]
+	}
+	return raw
+}
+
+func isWhitelistReason(reason string) bool {
+	reason = strings.ToLower(reason)
+	return strings.Contains(reason, "not whitelisted") || strings.Contains(reason, "not white-listed")
+}
+
+// pluginName drops the version of "Name v1.2.3".
+func pluginName(full string) string {
+	if i := strings.LastIndex(full, " v"); i > 0 {
+		return full[:i]
+	}
+	return full
+}
+
+// timeOfDay puts a log time on the date of now. A time later than now
+// belongs to the day before (a line from just before midnight).
+func timeOfDay(now time.Time, hh, mm, ss string) time.Time {
+	h, _ := strconv.Atoi(hh)
+	m, _ := strconv.Atoi(mm)
+	s, _ := strconv.Atoi(ss)
+	t := time.Date(now.Year(), now.Month(), now.Day(), h, m, s, 0, now.Location())
+	if t.After(now.Add(time.Minute)) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t
+}
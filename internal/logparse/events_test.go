@@ -0,0 +1,81 @@
+package logparse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// checkGolden runs every line of testdata/<name>.log through parse and compares
+// the results as JSON with testdata/<name>.golden (go test -update to
+// regenerate). Lines parse returns nil for are skipped.
+func checkGolden(t *testing.T, name string, parse func(line string) any) {
+	t.Helper()
+	input, err := os.Open(filepath.Join("testdata", name+".log"))
+	if err != nil {
+		t.Fatalf("[TEST] open corpus: %v", err)
+	}
+	defer input.Close()
+
+	var got bytes.Buffer
+	encoder := json.NewEncoder(&got)
+	encoder.SetEscapeHTML(false)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		if v := parse(scanner.Text()); v != nil {
+			encoder.Encode(v)
+		}
+	}
+
+	golden := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+			t.Fatalf("[TEST] write golden: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("[TEST] read golden: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("[TEST] output differs from %s, run go test -update and review the diff\ngot:\n%s", golden, got.String())
+	}
+}
+
+// TestParseGolden compares the events of testdata/events.log with testdata/events.golden.
+func TestParseGolden(t *testing.T) {
+	parser := NewParser()
+	parser.Now = func() time.Time { return time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC) }
+	checkGolden(t, "events", func(line string) any {
+		if ev, ok := parser.Parse(line); ok {
+			return ev
+		}
+		return nil
+	})
+}
+
+func TestTimeOfDay(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 0, 0, 30, 0, time.UTC)
+	tests := []struct {
+		name string
+		hh   string
+		want time.Time
+	}{
+		{"Same day", "00", time.Date(2025, time.January, 15, 0, 0, 10, 0, time.UTC)},
+		{"Before midnight", "23", time.Date(2025, time.January, 14, 23, 0, 10, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeOfDay(now, tt.hh, "00", "10"); !got.Equal(tt.want) {
+				t.Errorf("[TEST] timeOfDay() = %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package logparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a console line split into its fields.
+type Record struct {
+	Time    time.Time `json:"time,omitzero"` // zero for lines without a header (stack traces)
+	Level   string    `json:"level,omitempty"`
+	Thread  string    `json:"thread,omitempty"` // only in the logs/latest.log format
+	Logger  string    `json:"logger,omitempty"` // plugin or component of "[LuckPerms] message"
+	Message string    `json:"message"`          // the text after the header, without colors
+	Spans   []Span    `json:"spans"`            // Message with its colors and formatting
+}
+
+// Span is a run of text with the same style.
+type Span struct {
+	Text          string `json:"text"`
+	Color         string `json:"color,omitempty"` // "#rrggbb", empty for the default color
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Obfuscated    bool   `json:"obfuscated,omitempty"`
+}
+
+var (
+	recordRegex = regexp.MustCompile(`^\[(\d{2}):(\d{2}):(\d{2})(?: ([A-Z]+))?\](?: \[([^\]]*)/([A-Z]+)\])?: ?`)
+	loggerRegex = regexp.MustCompile(`^\[([A-Za-z0-9_.\- ]{1,64})\] `)
+)
+
+// ParseLine splits a line in either console format, "[12:34:56 INFO]: [Logger] message"
+// or "[12:34:56] [Server thread/INFO]: [Logger] message". The time of day is
+// put on the date of now. A line without a header is all Message.
+func ParseLine(line string, now time.Time) Record {
+	spans := Spans(strings.TrimRight(line, "\r\n"))
+	message := plainText(spans)
+
+	// 1. Header
+	var rec Record
+	if m := recordRegex.FindStringSubmatch(message); m != nil {
+		rec.Time = timeOfDay(now, m[1], m[2], m[3])
+		rec.Level, rec.Thread = m[4], m[5]
+		if rec.Level == "" {
+			rec.Level = m[6]
+		}
+		spans = trimSpans(spans, len(m[0]))
+		message = message[len(m[0]):]
+	}
+
+	// 2. Logger, chat's "[Not Secure] <Steve>" is not one
+	if m := loggerRegex.FindStringSubmatch(message); m != nil && m[1] != "Not Secure" {
+		rec.Logger = m[1]
+		spans = trimSpans(spans, len(m[0]))
+		message = message[len(m[0]):]
+	}
+
+	rec.Message = message
+	rec.Spans = spans
+	return rec
+}
+
+// Plain returns line without its ANSI and § codes.
+func Plain(line string) string {
+	return plainText(Spans(line))
+}
+
+func plainText(spans []Span) string {
+	var plain strings.Builder
+	for _, span := range spans {
+		plain.WriteString(span.Text)
+	}
+	return plain.String()
+}
+
+// trimSpans drops the first n bytes of text.
+func trimSpans(spans []Span, n int) []Span {
+	for len(spans) > 0 && n > 0 {
+		if len(spans[0].Text) > n {
+			spans[0].Text = spans[0].Text[n:]
+			break
+		}
+		n -= len(spans[0].Text)
+		spans = spans[1:]
+	}
+	return spans
+}
+
+// Minecraft's 16 colors, by § code. The ANSI colors Paper prints map to the same.
+var mcColors = map[byte]string{
+	'0': "#000000", '1': "#0000AA", '2': "#00AA00", '3': "#00AAAA",
+	'4': "#AA0000", '5': "#AA00AA", '6': "#FFAA00", '7': "#AAAAAA",
+	'8': "#555555", '9': "#5555FF", 'a': "#55FF55", 'b': "#55FFFF",
+	'c': "#FF5555", 'd': "#FF55FF", 'e': "#FFFF55", 'f': "#FFFFFF",
+}
+
+// ansiColors maps the 16 ANSI colors (30-37, then 90-97) to Minecraft's.
+var ansiColors = [16]string{
+	"#000000", "#AA0000", "#00AA00", "#FFAA00", "#0000AA", "#AA00AA", "#00AAAA", "#AAAAAA",
+	"#555555", "#FF5555", "#55FF55", "#FFFF55", "#5555FF", "#FF55FF", "#55FFFF", "#FFFFFF",
+}
+
+// Spans converts ANSI escape sequences and § codes into styled text runs.
+func Spans(line string) []Span {
+	spans := []Span{}
+	var style Span
+	var text strings.Builder
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		span := style
+		span.Text = text.String()
+		text.Reset()
+		// Merge with the previous run when nothing changed
+		if n := len(spans); n > 0 && sameStyle(spans[n-1], span) {
+			spans[n-1].Text += span.Text
+			return
+		}
+		spans = append(spans, span)
+	}
+
+	for i := 0; i < len(line); {
+		switch {
+		case line[i] == '\x1b' && i+1 < len(line) && line[i+1] == '[':
+			// 1. ANSI CSI: ESC [ params final, only SGR ("m") carries a style
+			end := i + 2
+			for end < len(line) && (line[end] < 0x40 || line[end] > 0x7e) {
+				end++
+			}
+			if end == len(line) {
+				i = end
+				continue
+			}
+			if line[end] == 'm' {
+				flush()
+				applySGR(&style, line[i+2:end])
+			}
+			i = end + 1
+
+		case strings.HasPrefix(line[i:], "§") && i+len("§") < len(line):
+			// 2. Minecraft formatting code, §x§R§R§G§G§B§B is a hex color
+			code := lower(line[i+len("§")])
+			i += len("§") + 1
+			if code == 'x' {
+				if hex, ok := hexCode(line[i:]); ok {
+					flush()
+					style = Span{Color: "#" + hex}
+					i += 6 * (len("§") + 1)
+				}
+				continue
+			}
+			flush()
+			applyCode(&style, code)
+
+		default:
+			text.WriteByte(line[i])
+			i++
+		}
+	}
+	flush()
+	return spans
+}
+
+func sameStyle(a, b Span) bool {
+	a.Text, b.Text = "", ""
+	return a == b
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// hexCode reads the six "§R" pairs that follow "§x".
+func hexCode(s string) (string, bool) {
+	var hex strings.Builder
+	for range 6 {
+		if !strings.HasPrefix(s, "§") || len(s) < len("§")+1 {
+			return "", false
+		}
+		c := lower(s[len("§")])
+		if !strings.ContainsRune("0123456789abcdef", rune(c)) {
+			return "", false
+		}
+		hex.WriteByte(c)
+		s = s[len("§")+1:]
+	}
+	return strings.ToUpper(hex.String()), true
+}
+
+// applyCode applies a § code. Like in the game, a color resets the formatting.
+func applyCode(style *Span, code byte) {
+	if color, ok := mcColors[code]; ok {
+		*style = Span{Color: color}
+		return
+	}
+	switch code {
+	case 'k':
+		style.Obfuscated = true
+	case 'l':
+		style.Bold = true
+	case 'm':
+		style.Strikethrough = true
+	case 'n':
+		style.Underline = true
+	case 'o':
+		style.Italic = true
+	case 'r':
+		*style = Span{}
+	}
+}
+
+// applySGR applies the parameters of an ANSI "ESC[...m" sequence.
+func applySGR(style *Span, params string) {
+	if params == "" {
+		*style = Span{}
+		return
+	}
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		n, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			*style = Span{}
+		case n == 1:
+			style.Bold = true
+		case n == 3:
+			style.Italic = true
+		case n == 4:
+			style.Underline = true
+		case n == 5:
+			style.Obfuscated = true // blink, the closest thing to §k
+		case n == 9:
+			style.Strikethrough = true
+		case n == 22:
+			style.Bold = false
+		case n == 23:
+			style.Italic = false
+		case n == 24:
+			style.Underline = false
+		case n == 25:
+			style.Obfuscated = false
+		case n == 29:
+			style.Strikethrough = false
+		case n >= 30 && n <= 37:
+			style.Color = ansiColors[n-30]
+		case n >= 90 && n <= 97:
+			style.Color = ansiColors[n-90+8]
+		case n == 39:
+			style.Color = ""
+		case n == 38 && i+4 < len(codes) && codes[i+1] == "2":
+			// Truecolor: 38;2;r;g;b
+			r, _ := strconv.Atoi(codes[i+2])
+			g, _ := strconv.Atoi(codes[i+3])
+			b, _ := strconv.Atoi(codes[i+4])
+			style.Color = fmt.Sprintf("#%02X%02X%02X", r&0xff, g&0xff, b&0xff)
+			i += 4
+		case n == 38 && i+2 < len(codes) && codes[i+1] == "5":
+			// 256 colors: 38;5;n
+			c, _ := strconv.Atoi(codes[i+2])
+			style.Color = color256(c)
+			i += 2
+		}
+	}
+}
+
+// color256 converts an xterm 256 color index.
+func color256(c int) string {
+	switch {
+	case c < 0 || c > 255:
+		return ""
+	case c < 16:
+		return ansiColors[c]
+	case c < 232:
+		// 6x6x6 cube
+		c -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02X%02X%02X", level(c/36), level(c/6%6), level(c%6))
+	default:
+		gray := 8 + (c-232)*10
+		return fmt.Sprintf("#%02X%02X%02X", gray, gray, gray)
+	}
+}
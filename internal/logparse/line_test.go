@@ -0,0 +1,14 @@
+package logparse
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseLineGolden compares the records of testdata/lines.log with testdata/lines.golden.
+func TestParseLineGolden(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 12, 30, 0, 0, time.UTC)
+	checkGolden(t, "lines", func(line string) any {
+		return ParseLine(line, now)
+	})
+}
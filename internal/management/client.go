@@ -0,0 +1,205 @@
+// Package management is a client for the Minecraft Server Management
+// Protocol (Java Edition 1.21.9+): JSON-RPC 2.0 over a websocket, enabled with
+// management-server-enabled=true in server.properties and authenticated with
+// management-server-secret.
+package management
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrClosed is returned by calls on a connection that went away.
+var ErrClosed = errors.New("management: connection closed")
+
+// RPCError is an error answered by the server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("management: %s (%d): %s", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("management: %s (%d)", e.Message, e.Code)
+}
+
+// Notification is pushed by the server without a request, for example
+// "minecraft:notification/players/joined".
+type Notification struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// message is any JSON-RPC frame, request, response or notification.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// Options configure Dial.
+type Options struct {
+	Secret string      // management-server-secret, sent as a bearer token
+	TLS    *tls.Config // for wss:// URLs, nil uses the system roots
+	// Notifications is the buffer of Client.Notifications, notifications
+	// that don't fit are dropped. 0 means 64.
+	Notifications int
+}
+
+// Client is one connection. Calls may be made concurrently.
+type Client struct {
+	conn          *websocket.Conn
+	writeMu       sync.Mutex
+	mu            sync.Mutex
+	nextID        int64
+	pending       map[int64]chan message
+	notifications chan Notification
+	done          chan struct{}
+	err           error // why the connection closed, set before done is closed
+}
+
+// Dial connects to url ("ws://localhost:25585" or "wss://...").
+func Dial(ctx context.Context, url string, opts Options) (*Client, error) {
+	dialer := websocket.Dialer{TLSClientConfig: opts.TLS, HandshakeTimeout: 10 * time.Second}
+	header := http.Header{}
+	if opts.Secret != "" {
+		header.Set("Authorization", "Bearer "+opts.Secret)
+	}
+	conn, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("management: wrong secret: %w", err)
+		}
+		return nil, err
+	}
+	buffer := opts.Notifications
+	if buffer <= 0 {
+		buffer = 64
+	}
+	c := &Client{
+		conn:          conn,
+		pending:       make(map[int64]chan message),
+		notifications: make(chan Notification, buffer),
+		done:          make(chan struct{}),
+	}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches responses to their callers and queues notifications.
+func (c *Client) read() {
+	var err error
+	for {
+		var msg message
+		if err = c.conn.ReadJSON(&msg); err != nil {
+			break
+		}
+		if msg.ID == nil {
+			if msg.Method == "" {
+				continue
+			}
+			params, _ := json.Marshal(msg.Params)
+			select {
+			case c.notifications <- Notification{Method: msg.Method, Params: params}:
+			default:
+			}
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+	close(c.notifications)
+	c.conn.Close()
+}
+
+// Notifications delivers what the server pushes. It is closed with the connection.
+func (c *Client) Notifications() <-chan Notification {
+	return c.notifications
+}
+
+// Done is closed when the connection is gone, Err tells why.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Call invokes method with positional params and decodes the result into
+// result (skipped when nil).
+func (c *Client) Call(ctx context.Context, method string, params []any, result any) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	req := message{JSONRPC: "2.0", ID: &id, Method: method}
+	if params != nil {
+		req.Params = params
+	}
+	c.writeMu.Lock()
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetWriteDeadline(deadline)
+	}
+	err := c.conn.WriteJSON(req)
+	c.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	case <-c.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) Close() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return c.conn.Close()
+}
@@ -0,0 +1,122 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeServer is a JSON-RPC server with an in-memory allowlist. It greets
+// every connection with a players/joined notification.
+func fakeServer(t *testing.T, secret string) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	allowlist := []Player{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+secret {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "method": NotifyPlayerJoined, "params": []Player{{Name: "Steve"}}})
+
+		for {
+			var req struct {
+				ID     int64             `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+			switch req.Method {
+			case "minecraft:allowlist":
+				resp["result"] = allowlist
+			case "minecraft:allowlist/add":
+				var players []Player
+				json.Unmarshal(req.Params[0], &players)
+				for _, p := range players {
+					if p.Name != "Nobody" {
+						allowlist = append(allowlist, p)
+					}
+				}
+				resp["result"] = allowlist
+			default:
+				resp["error"] = RPCError{Code: -32601, Message: "Method not found"}
+			}
+			conn.WriteJSON(resp)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestClient(t *testing.T) {
+	url := fakeServer(t, "secret")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Dial(ctx, url, Options{Secret: "secret"})
+	if err != nil {
+		t.Fatalf("[TEST] Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	// 1. Notification pushed on connect
+	select {
+	case n := <-client.Notifications():
+		if players := PlayersOf(n); n.Method != NotifyPlayerJoined || len(players) != 1 || players[0].Name != "Steve" {
+			t.Errorf("[TEST] notification = %+v, want: Steve joined", n)
+		}
+	case <-ctx.Done():
+		t.Fatal("[TEST] no notification")
+	}
+
+	// 2. Typed calls
+	tests := []struct {
+		name string
+		add  string
+		want int // allowlist length after the call
+	}{
+		{"Add a player", "Alex", 1},
+		{"Unknown player is ignored", "Nobody", 1},
+		{"Add another", "Steve", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := client.AllowlistAdd(ctx, PlayersNamed(tt.add)...)
+			if err != nil {
+				t.Fatalf("[TEST] AllowlistAdd failed: %v", err)
+			}
+			if len(list) != tt.want {
+				t.Errorf("[TEST] allowlist = %v, want %d players", list, tt.want)
+			}
+		})
+	}
+
+	// 3. Errors answered by the server
+	var rpcErr *RPCError
+	if _, err := client.Operators(ctx); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Errorf("[TEST] Operators error = %v, want: method not found", err)
+	}
+}
+
+func TestWrongSecret(t *testing.T) {
+	url := fakeServer(t, "secret")
+	if _, err := Dial(context.Background(), url, Options{Secret: "guess"}); err == nil {
+		t.Errorf("[TEST] Dial with a wrong secret succeeded")
+	}
+}
@@ -0,0 +1,175 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Notification methods the backend reacts to
+const (
+	NotifyPlayerJoined  = "minecraft:notification/players/joined"
+	NotifyPlayerLeft    = "minecraft:notification/players/left"
+	NotifyServerStarted = "minecraft:notification/server/started"
+	NotifyServerStop    = "minecraft:notification/server/stopping"
+)
+
+// Player identifies a player by name, ID (UUID) or both.
+type Player struct {
+	Name string `json:"name,omitempty"`
+	ID   string `json:"id,omitempty"`
+}
+
+// Message is a chat text.
+type Message struct {
+	Literal string `json:"literal,omitempty"`
+}
+
+type UserBan struct {
+	Player  Player `json:"player"`
+	Reason  string `json:"reason,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Expires string `json:"expires,omitempty"` // RFC3339, empty for a permanent ban
+}
+
+type Operator struct {
+	Player              Player `json:"player"`
+	PermissionLevel     int    `json:"permissionLevel,omitempty"` // 1 to 4
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit,omitempty"`
+}
+
+type KickPlayer struct {
+	Player  Player  `json:"player"`
+	Message Message `json:"message"`
+}
+
+type Version struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+type ServerState struct {
+	Started bool     `json:"started"`
+	Players []Player `json:"players"`
+	Version Version  `json:"version"`
+}
+
+// PlayersNamed returns players identified by name only.
+func PlayersNamed(names ...string) []Player {
+	players := make([]Player, len(names))
+	for i, name := range names {
+		players[i] = Player{Name: name}
+	}
+	return players
+}
+
+// --- Allowlist ---
+
+func (c *Client) Allowlist(ctx context.Context) ([]Player, error) {
+	var players []Player
+	err := c.Call(ctx, "minecraft:allowlist", nil, &players)
+	return players, err
+}
+
+// AllowlistAdd returns the allowlist after the change.
+func (c *Client) AllowlistAdd(ctx context.Context, players ...Player) ([]Player, error) {
+	var result []Player
+	err := c.Call(ctx, "minecraft:allowlist/add", []any{players}, &result)
+	return result, err
+}
+
+func (c *Client) AllowlistRemove(ctx context.Context, players ...Player) ([]Player, error) {
+	var result []Player
+	err := c.Call(ctx, "minecraft:allowlist/remove", []any{players}, &result)
+	return result, err
+}
+
+// --- Bans ---
+
+func (c *Client) Bans(ctx context.Context) ([]UserBan, error) {
+	var bans []UserBan
+	err := c.Call(ctx, "minecraft:bans", nil, &bans)
+	return bans, err
+}
+
+// BanAdd returns the ban list after the change.
+func (c *Client) BanAdd(ctx context.Context, bans ...UserBan) ([]UserBan, error) {
+	var result []UserBan
+	err := c.Call(ctx, "minecraft:bans/add", []any{bans}, &result)
+	return result, err
+}
+
+func (c *Client) BanRemove(ctx context.Context, players ...Player) ([]UserBan, error) {
+	var result []UserBan
+	err := c.Call(ctx, "minecraft:bans/remove", []any{players}, &result)
+	return result, err
+}
+
+// --- Operators ---
+
+func (c *Client) Operators(ctx context.Context) ([]Operator, error) {
+	var ops []Operator
+	err := c.Call(ctx, "minecraft:operators", nil, &ops)
+	return ops, err
+}
+
+// OperatorAdd returns the operator list after the change.
+func (c *Client) OperatorAdd(ctx context.Context, ops ...Operator) ([]Operator, error) {
+	var result []Operator
+	err := c.Call(ctx, "minecraft:operators/add", []any{ops}, &result)
+	return result, err
+}
+
+func (c *Client) OperatorRemove(ctx context.Context, players ...Player) ([]Operator, error) {
+	var result []Operator
+	err := c.Call(ctx, "minecraft:operators/remove", []any{players}, &result)
+	return result, err
+}
+
+// --- Players and server ---
+
+// Players returns the connected players.
+func (c *Client) Players(ctx context.Context) ([]Player, error) {
+	var players []Player
+	err := c.Call(ctx, "minecraft:players", nil, &players)
+	return players, err
+}
+
+// Kick returns the players that were kicked.
+func (c *Client) Kick(ctx context.Context, kicks ...KickPlayer) ([]Player, error) {
+	var result []Player
+	err := c.Call(ctx, "minecraft:players/kick", []any{kicks}, &result)
+	return result, err
+}
+
+func (c *Client) Status(ctx context.Context) (ServerState, error) {
+	var state ServerState
+	err := c.Call(ctx, "minecraft:server/status", nil, &state)
+	return state, err
+}
+
+// SystemMessage shows text to every player, in chat or above the hotbar.
+func (c *Client) SystemMessage(ctx context.Context, text string, overlay bool) error {
+	params := map[string]any{"message": Message{Literal: text}, "overlay": overlay}
+	return c.Call(ctx, "minecraft:server/system_message", []any{params}, nil)
+}
+
+// Setting reads a server setting ("difficulty", "max_players", "motd"...)
+// into value.
+func (c *Client) Setting(ctx context.Context, name string, value any) error {
+	return c.Call(ctx, "minecraft:serversettings/"+name, nil, value)
+}
+
+// SetSetting changes a server setting, the applied value is returned.
+func (c *Client) SetSetting(ctx context.Context, name string, value any) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := c.Call(ctx, "minecraft:serversettings/"+name+"/set", []any{value}, &result)
+	return result, err
+}
+
+// PlayersOf decodes the positional params of a players/joined or
+// players/left notification.
+func PlayersOf(n Notification) []Player {
+	var params []Player
+	json.Unmarshal(n.Params, &params)
+	return params
+}
@@ -0,0 +1,109 @@
+package minecraft
+
+import (
+	"log"
+	"time"
+
+	"paperMC_backend/internal/database"
+)
+
+const (
+	archiveBuffer   = 4096 // lines queued while SQLite is busy
+	archiveBatch    = 256
+	archiveInterval = time.Second
+	pruneInterval   = time.Hour
+)
+
+// LogRetention limits the console log archive.
+type LogRetention struct {
+	MaxAge   time.Duration // 0 keeps lines forever
+	MaxLines int           // per server, 0 = no limit
+}
+
+// startArchive writes every console line of a server to the archive, in
+// batches so a chatty server doesn't turn into one transaction per line.
+func (m *ServerManager) startArchive(server *Server) {
+	sub := server.Hub.Subscribe(archiveBuffer, DropWithGap)
+	server.archive = sub
+
+	go func() {
+		batch := make([]database.ConsoleLog, 0, archiveBatch)
+		ticker := time.NewTicker(archiveInterval)
+		defer ticker.Stop()
+
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			if err := m.store.InsertConsoleLogs(batch); err != nil {
+				log.Printf("[Archive] Failed to save %d lines of %s: %v", len(batch), server.ID, err)
+			}
+			batch = batch[:0]
+		}
+
+		for {
+			select {
+			case msg, open := <-sub.C():
+				if !open {
+					flush()
+					return
+				}
+				switch msg.Kind {
+				case KindLog:
+					batch = append(batch, archiveLine(server.ID, *msg.Log))
+				case KindGap:
+					log.Printf("[Archive] %d messages of %s were not archived, the database is too slow", msg.Dropped, server.ID)
+				}
+				if len(batch) >= archiveBatch {
+					flush()
+				}
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+}
+
+// archiveLine is the archive row of a console line.
+func archiveLine(instanceID string, line LogLine) database.ConsoleLog {
+	return database.ConsoleLog{
+		InstanceID: instanceID,
+		Seq:        line.Seq,
+		Time:       line.Time,
+		Level:      line.Level,
+		Source:     line.Stream,
+		Line:       CleanString(line.Text),
+	}
+}
+
+// stopArchive must be called with m.mu held, when a server is deleted.
+func stopArchive(server *Server) {
+	if server.archive != nil {
+		server.archive.Close()
+	}
+}
+
+// pruneLogs enforces the retention limits until Close.
+func (m *ServerManager) pruneLogs() {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		if m.options.Retention.MaxAge > 0 || m.options.Retention.MaxLines > 0 {
+			before := time.Time{}
+			if m.options.Retention.MaxAge > 0 {
+				before = time.Now().Add(-m.options.Retention.MaxAge)
+			}
+			deleted, err := m.store.PruneConsoleLogs(before, m.options.Retention.MaxLines)
+			if err != nil {
+				log.Printf("[Archive] Pruning failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("[Archive] Pruned %d old console lines", deleted)
+			}
+		}
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package minecraft
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/logparse"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// A detached server keeps its console in the work dir instead of in pipes
+// owned by the backend, so it survives a backend restart:
+//
+//	.panel/stdin        named pipe the console commands are written to
+//	.panel/console.log  stdout of the current run, emptied past maxConsoleFile
+//	.panel/stderr.log   stderr of the current run
+//	.panel/server.pid   PID of the java process
+//	.panel/state.json   runState, used to find the process again
+const (
+	runDirName  = ".panel"
+	stdinFile   = "stdin"
+	consoleFile = "console.log"
+	stderrFile  = "stderr.log"
+	pidFile     = "server.pid"
+	stateFile   = "state.json"
+)
+
+// runState is written when a detached server starts and removed when it exits.
+type runState struct {
+	PID        int       `json:"pid"`
+	CreateTime int64     `json:"create_time"` // ms since epoch, guards against PID reuse
+	StartedAt  time.Time `json:"started_at"`
+	Command    []string  `json:"command"`
+}
+
+func runPath(workDir, name string) string {
+	return filepath.Join(workDir, runDirName, name)
+}
+
+func writeRunState(workDir string, state runState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(runPath(workDir, pidFile), []byte(strconv.Itoa(state.PID)+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(runPath(workDir, stateFile), data, 0644)
+}
+
+func readRunState(workDir string) (runState, error) {
+	var state runState
+	data, err := os.ReadFile(runPath(workDir, stateFile))
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// removeRunState forgets the process. The console files stay for post-mortems.
+func removeRunState(workDir string) {
+	os.Remove(runPath(workDir, pidFile))
+	os.Remove(runPath(workDir, stateFile))
+}
+
+// Adopt re-attaches to a detached server left running by a previous backend.
+// It reports false when there is nothing to adopt.
+func (s *Server) Adopt() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != StatusStopped && s.status != StatusCrashed {
+		return false, nil
+	}
+	state, err := readRunState(s.WorkDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// 1. Make sure it is still our java process
+	proc, err := process.NewProcess(int32(state.PID))
+	if err != nil || !sameProcess(proc, state.CreateTime) {
+		// It died while the backend was away
+		removeRunState(s.WorkDir)
+		return false, nil
+	}
+
+	// 2. Re-open the console. O_RDWR never blocks on a FIFO
+	stdin, err := os.OpenFile(runPath(s.WorkDir, stdinFile), os.O_RDWR, 0)
+	if err != nil {
+		return false, err
+	}
+	console, err := os.Open(runPath(s.WorkDir, consoleFile))
+	if err != nil {
+		stdin.Close()
+		return false, err
+	}
+	// Created when missing, servers started by older versions had no stderr file
+	errs, err := os.OpenFile(runPath(s.WorkDir, stderrFile), os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		stdin.Close()
+		console.Close()
+		return false, err
+	}
+	closeAll := func() {
+		stdin.Close()
+		console.Close()
+		errs.Close()
+	}
+	p, err := adopt(proc, state.CreateTime)
+	if err != nil {
+		closeAll()
+		return false, err
+	}
+
+	// 3. Catch up with what was printed while we were away
+	booted, err := s.replay(console, StreamStdout)
+	if err == nil {
+		_, err = s.replay(errs, StreamStderr)
+	}
+	if err != nil {
+		closeAll()
+		return false, err
+	}
+
+	s.process = p
+	s.stdin = stdin
+	s.stdout = &logTail{file: console, exited: p.exited, limit: maxConsoleFile}
+	s.stderr = &logTail{file: errs, exited: p.exited, limit: maxConsoleFile}
+	s.proc = proc
+	s.done = make(chan struct{})
+	s.stopRequested = false
+	s.stopLogged = false
+	s.bootFailure = ""
+	if booted {
+		s.setStatus(StatusRunning)
+	} else {
+		s.setStatus(StatusStarting)
+	}
+	go s.supervise(p, s.stdout, s.stderr, s.done)
+	return true, nil
+}
+
+// replay feeds the console written so far into the history and the archive
+// and, for stdout, the online players and the boot state, without re-running
+// side effects like persisting rejected players. The file is left at the end
+// of the last complete line. The caller must hold s.mu.
+func (s *Server) replay(console *os.File, stream string) (bool, error) {
+	info, err := console.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// Straight to the store in batches, a long console would overflow the
+	// archive's subscription. The file can't tell which lines the previous
+	// backend archived already, those are archived twice.
+	batch := make([]database.ConsoleLog, 0, archiveBatch)
+	flush := func() {
+		if len(batch) == 0 || s.store == nil {
+			return
+		}
+		if err := s.store.InsertConsoleLogs(batch); err != nil {
+			log.Printf("[Archive] Failed to save %d replayed lines of %s: %v", len(batch), s.ID, err)
+		}
+		batch = batch[:0]
+	}
+	defer flush()
+
+	booted := false
+	var offset int64
+	reader := bufio.NewReader(io.LimitReader(console, info.Size()))
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// A partial line is read again by the tail
+			break
+		}
+		offset += int64(len(line))
+
+		text := strings.TrimRight(line, "\r\n")
+		logLine := recordLine(stream, text)
+		// When it was printed, not when we caught up
+		logLine.Time = logLine.Record.Time
+		s.stamp(&logLine)
+		s.appendHistory(logLine)
+		if batch = append(batch, archiveLine(s.ID, logLine)); len(batch) >= archiveBatch {
+			flush()
+		}
+		if stream == StreamStderr {
+			continue
+		}
+		cleanText := CleanString(text)
+		if matches := uuidLogRegex.FindStringSubmatch(cleanText); len(matches) == 3 {
+			s.uuidCache[matches[1]] = matches[2]
+		}
+		ev, ok := s.parser.Parse(text)
+		if !ok {
+			continue
+		}
+		switch ev.Type {
+		case logparse.TypeDone:
+			booted = true
+		case logparse.TypeJoin, logparse.TypeLeave:
+			s.updateSession(ev)
+		}
+	}
+
+	_, err = console.Seek(offset, io.SeekStart)
+	return booted, err
+}
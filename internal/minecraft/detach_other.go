@@ -0,0 +1,13 @@
+//go:build !unix
+
+package minecraft
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+func startDetached(cmd *exec.Cmd, workDir string) (*os.File, *os.File, *os.File, error) {
+	return nil, nil, nil, errors.New("detached servers need named pipes, set MC_DETACH=false on this platform")
+}
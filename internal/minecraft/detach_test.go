@@ -0,0 +1,212 @@
+//go:build unix
+
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"paperMC_backend/internal/database"
+)
+
+func TestRunState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, runDirName), 0755); err != nil {
+		t.Fatalf("[TEST] MkdirAll failed: %v", err)
+	}
+
+	state := runState{PID: 1234, CreateTime: 1700000000000, StartedAt: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), Command: []string{"java", "-jar", "server.jar"}}
+	if err := writeRunState(dir, state); err != nil {
+		t.Fatalf("[TEST] writeRunState failed: %v", err)
+	}
+	got, err := readRunState(dir)
+	if err != nil || got.PID != state.PID || got.CreateTime != state.CreateTime || !got.StartedAt.Equal(state.StartedAt) || !slices.Equal(got.Command, state.Command) {
+		t.Errorf("[TEST] readRunState = %+v, %v, want: %+v", got, err, state)
+	}
+	if pid, _ := os.ReadFile(runPath(dir, pidFile)); string(pid) != "1234\n" {
+		t.Errorf("[TEST] %s = %q, want: %q", pidFile, pid, "1234\n")
+	}
+
+	removeRunState(dir)
+	if _, err := readRunState(dir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("[TEST] readRunState after remove = %v, want: %v", err, fs.ErrNotExist)
+	}
+	if _, err := os.Stat(runPath(dir, pidFile)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("[TEST] %s not removed: %v", pidFile, err)
+	}
+}
+
+func TestAdoptNothing(t *testing.T) {
+	tests := []struct {
+		name  string
+		state *runState // nil writes no state
+	}{
+		{"No run state", nil},
+		{"Process gone", &runState{PID: 1 << 22}},
+		{"PID reused", &runState{PID: os.Getpid(), CreateTime: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.MkdirAll(filepath.Join(dir, runDirName), 0755)
+			if tt.state != nil {
+				if err := writeRunState(dir, *tt.state); err != nil {
+					t.Fatalf("[TEST] writeRunState failed: %v", err)
+				}
+			}
+
+			s := NewServer(dir, "server.jar", "1G", nil)
+			adopted, err := s.Adopt()
+			if adopted || err != nil {
+				t.Errorf("[TEST] Adopt = %v, %v, want: false, nil", adopted, err)
+			}
+			if _, err := readRunState(dir); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("[TEST] stale run state kept: %v", err)
+			}
+		})
+	}
+}
+
+// runDetached starts a detached server and leaves it running, like a
+// backend going away without stopping it.
+func runDetached(t *testing.T) *Server {
+	t.Helper()
+	first := fakeJava(t, doneLine+"\n"+`while read cmd; do [ "$cmd" = stop ] && echo '[12:00:01 INFO]: Stopping server' && exit 0; done`)
+	first.Detach = true
+	first.Restart.Enabled = false
+	if err := first.Start(); err != nil {
+		t.Fatalf("[TEST] Start failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := first.WaitReady(ctx); err != nil {
+		t.Fatalf("[TEST] WaitReady failed: %v", err)
+	}
+	if state, err := readRunState(first.WorkDir); err != nil || state.PID != first.PID() {
+		t.Fatalf("[TEST] run state = %+v, %v, want PID %d", state, err, first.PID())
+	}
+	return first
+}
+
+func TestDetachAdopt(t *testing.T) {
+	first := runDetached(t)
+
+	// The next one finds it again
+	store, err := database.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("[TEST] NewSQLiteStore error = %v", err)
+	}
+	defer store.Close()
+	second := NewServer(first.WorkDir, "server.jar", "1G", store)
+	second.ID = "default"
+	second.seq = 41 // where the archive stopped
+	sub := second.Hub.Subscribe(64, DropWithGap)
+	defer sub.Close()
+
+	adopted, err := second.Adopt()
+	if !adopted || err != nil {
+		t.Fatalf("[TEST] Adopt = %v, %v, want: true, nil", adopted, err)
+	}
+	if status := second.GetStatus(); status != StatusRunning {
+		t.Errorf("[TEST] status after adopt = %s, want: %s", status, StatusRunning)
+	}
+
+	// The replayed console is numbered and archived like live lines
+	history := second.GetHistory()
+	if len(history) != 1 || history[0].Seq != 42 || !strings.Contains(history[0].Text, "Done") {
+		t.Fatalf("[TEST] history = %+v, want the Done line as seq 42", history)
+	}
+	archived, err := store.SearchConsoleLogs(database.ConsoleLogQuery{InstanceID: "default", Limit: 10})
+	if err != nil || len(archived) != 1 || archived[0].Seq != 42 {
+		t.Errorf("[TEST] archived = %+v, %v, want the Done line as seq 42", archived, err)
+	}
+
+	// Commands reach the adopted process, "Stopping server" makes its exit expected
+	if err := second.SendCommand("stop"); err != nil {
+		t.Fatalf("[TEST] SendCommand failed: %v", err)
+	}
+	if got, want := lifecycle(t, sub, 1), []Status{StatusRunning, StatusStopped}; !slices.Equal(got, want) {
+		t.Errorf("[TEST] statuses = %v, want: %v", got, want)
+	}
+	if _, err := readRunState(first.WorkDir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("[TEST] run state after exit = %v, want: %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestDetachAdoptStop(t *testing.T) {
+	first := runDetached(t)
+	second := NewServer(first.WorkDir, "server.jar", "1G", nil)
+	if adopted, err := second.Adopt(); !adopted || err != nil {
+		t.Fatalf("[TEST] Adopt = %v, %v, want: true, nil", adopted, err)
+	}
+
+	// No exit code for an adopted process, a clean stop is still no error
+	stage, err := second.StopWithTimeout(5 * time.Second)
+	if stage != StopStageCommand || err != nil {
+		t.Errorf("[TEST] StopWithTimeout = %s, %v, want: %s, nil", stage, err, StopStageCommand)
+	}
+	if status := second.GetStatus(); status != StatusStopped {
+		t.Errorf("[TEST] status after stop = %s, want: %s", status, StatusStopped)
+	}
+}
+
+func TestLogTailRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), consoleFile)
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("[TEST] OpenFile failed: %v", err)
+	}
+	defer writer.Close()
+	reader, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("[TEST] Open failed: %v", err)
+	}
+	exited := make(chan struct{})
+	tail := &logTail{file: reader, exited: exited, limit: 8}
+	defer tail.Close()
+
+	writer.WriteString("first line\n")
+	buf := make([]byte, 64)
+	if n, err := tail.Read(buf); string(buf[:n]) != "first line\n" || err != nil {
+		t.Fatalf("[TEST] Read = %q, %v, want: %q", buf[:n], err, "first line\n")
+	}
+
+	// The next read is past the limit at the end of the file, it empties it
+	read := make(chan string)
+	go func() {
+		n, _ := tail.Read(buf)
+		read <- string(buf[:n])
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("[TEST] %s was not emptied", consoleFile)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writer.WriteString("second\n")
+	if got := <-read; got != "second\n" {
+		t.Errorf("[TEST] Read after rotate = %q, want: %q", got, "second\n")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second\n" {
+		t.Errorf("[TEST] %s = %q, want: %q", consoleFile, data, "second\n")
+	}
+
+	close(exited)
+	if n, err := tail.Read(buf); n != 0 || err != io.EOF {
+		t.Errorf("[TEST] Read after exit = %d, %v, want: 0, EOF", n, err)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build unix
+
+package minecraft
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// startDetached starts cmd with its console in the work dir (see runState).
+// It returns the FIFO to write commands to and the stdout and stderr files to read from.
+func startDetached(cmd *exec.Cmd, workDir string) (*os.File, *os.File, *os.File, error) {
+	if err := os.MkdirAll(filepath.Join(workDir, runDirName), 0755); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 1. stdin is a named pipe. Opening it O_RDWR keeps a writer alive, so
+	// the server never reads EOF while no backend is attached.
+	fifoPath := runPath(workDir, stdinFile)
+	if err := syscall.Mkfifo(fifoPath, 0600); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, nil, nil, err
+	}
+	stdin, err := os.OpenFile(fifoPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// 2. stdout and stderr go to fresh console files
+	consolePath := runPath(workDir, consoleFile)
+	out, err := os.OpenFile(consolePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		stdin.Close()
+		return nil, nil, nil, err
+	}
+	defer out.Close() // the child has its own copy
+	stderrPath := runPath(workDir, stderrFile)
+	errOut, err := os.OpenFile(stderrPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		stdin.Close()
+		return nil, nil, nil, err
+	}
+	defer errOut.Close()
+
+	cmd.Stdin = stdin
+	cmd.Stdout = out
+	cmd.Stderr = errOut
+	// Own process group, a Ctrl-C aimed at the backend must not reach java
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return nil, nil, nil, err
+	}
+
+	console, err := os.Open(consolePath)
+	if err == nil {
+		var errs *os.File
+		if errs, err = os.Open(stderrPath); err == nil {
+			return stdin, console, errs, nil
+		}
+		console.Close()
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+	stdin.Close()
+	return nil, nil, nil, err
+}
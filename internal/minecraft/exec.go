@@ -0,0 +1,113 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/logparse"
+)
+
+// Over stdin there is no end of response marker: the output of a command is
+// whatever the console prints until it goes quiet.
+const (
+	execFirstLine = 2 * time.Second        // wait for the first line, some commands print nothing
+	execQuiet     = 300 * time.Millisecond // then wait this long after the last one
+)
+
+// ErrCommandRejected is wrapped by player operations when the server refused
+// the command ("That player does not exist").
+var ErrCommandRejected = errors.New("command rejected")
+
+// commandFailures start the replies of vanilla commands that failed. A change
+// that was already applied ("Nothing changed. The player is already an
+// operator", "Player is already whitelisted") is no failure, the management
+// protocol doesn't report one either.
+var commandFailures = []string{
+	"That player does not exist",
+	"No player was found",
+	"Unknown or incomplete command",
+	"Incorrect argument for command",
+}
+
+// ExecCommand runs cmd and returns its output, one line per entry without
+// the log header. RCON is used whenever server.properties enables it and it
+// answers, otherwise the command goes to stdin and the console lines that
+// follow are collected until it is quiet. Commands run one at a time so their
+// outputs don't mix, but over stdin a chat message or a plugin printing at
+// the same moment can still show up.
+func (s *Server) ExecCommand(ctx context.Context, cmd string) ([]string, error) {
+	s.execMu.Lock()
+	defer s.execMu.Unlock()
+
+	// 1. RCON answers exactly and at once, fall back only when it can't be
+	// reached (once a command was sent over RCON it must not be sent again)
+	if client, err := s.rconClient(); err == nil {
+		return s.rconCommand(client, cmd)
+	}
+
+	// 2. Listen before sending, the answer can come back immediately
+	sub := s.Hub.Subscribe(SubscriberBuffer, DropWithGap)
+	defer sub.Close()
+	if err := s.SendCommand(cmd); err != nil {
+		return nil, err
+	}
+
+	parser := logparse.NewParser()
+	lines := []string{}
+	timer := time.NewTimer(execFirstLine)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, open := <-sub.C():
+			if !open {
+				return lines, nil
+			}
+			if msg.Kind != KindLog || msg.Log.Stream != StreamStdout || msg.Log.Record == nil {
+				continue
+			}
+			// Players chatting or joining meanwhile are not part of the answer
+			if _, isEvent := parser.Parse(msg.Log.Record.Message); isEvent {
+				continue
+			}
+			lines = append(lines, msg.Log.Record.Message)
+			timer.Reset(execQuiet)
+		case <-timer.C:
+			return lines, nil
+		case <-ctx.Done():
+			return lines, ctx.Err()
+		}
+	}
+}
+
+// outputLines splits an RCON response, which keeps the § color codes.
+func outputLines(out string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(logparse.Plain(out), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// runChecked runs a command for a player operation and turns a vanilla
+// failure message into an error wrapping ErrCommandRejected.
+func (s *Server) runChecked(cmd string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lines, err := s.ExecCommand(ctx, cmd)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	for _, line := range lines {
+		for _, failure := range commandFailures {
+			if strings.HasPrefix(line, failure) {
+				return fmt.Errorf("%w: %s", ErrCommandRejected, line)
+			}
+		}
+	}
+	return nil
+}
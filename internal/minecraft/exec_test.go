@@ -0,0 +1,258 @@
+package minecraft
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"paperMC_backend/internal/config"
+	"paperMC_backend/internal/management"
+	"paperMC_backend/internal/rcon"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeConsole answers the commands written to the server's stdin like Paper would.
+func fakeConsole(t *testing.T, answers map[string][]string) *Server {
+	t.Helper()
+	reader, writer := io.Pipe()
+	t.Cleanup(func() { writer.Close() })
+
+	s := &Server{Hub: NewHub(), status: StatusRunning, stdin: writer}
+	go func() {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			for _, line := range answers[scanner.Text()] {
+				s.emit(recordLine(StreamStdout, line))
+			}
+		}
+	}()
+	return s
+}
+
+func TestExecCommand(t *testing.T) {
+	s := fakeConsole(t, map[string][]string{
+		"op Steve": {"[12:00:00 INFO]: Made Steve a server operator"},
+		"op Nobody": {
+			"[12:00:00 INFO]: <Alex> hi", // chat in between is not part of the answer
+			"[12:00:00 INFO]: That player does not exist",
+		},
+		"deop Steve": {"[12:00:00 INFO]: Nothing changed. The player is not an operator"},
+	})
+
+	tests := []struct {
+		name     string
+		cmd      string
+		rejected bool
+	}{
+		{"Accepted", "op Steve", false},
+		{"Unknown player", "op Nobody", true},
+		{"Already applied", "deop Steve", false},
+		{"No output", "save-all", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.runChecked(tt.cmd)
+			if rejected := errors.Is(err, ErrCommandRejected); rejected != tt.rejected {
+				t.Errorf("[TEST] runChecked(%q) = %v, want rejected: %v", tt.cmd, err, tt.rejected)
+			}
+		})
+	}
+
+	lines, err := s.ExecCommand(context.Background(), "op Nobody")
+	if err != nil {
+		t.Fatalf("[TEST] ExecCommand failed: %v", err)
+	}
+	if len(lines) != 1 || lines[0] != "That player does not exist" {
+		t.Errorf("[TEST] ExecCommand = %q, want: [That player does not exist]", lines)
+	}
+}
+
+// fakeRCON answers commands from answers over RCON and enables it in the
+// server.properties of dir.
+func fakeRCON(t *testing.T, dir string, answers map[string]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					p, err := rcon.ReadPacket(conn)
+					if err != nil {
+						return
+					}
+					switch p.Type {
+					case rcon.TypeAuth:
+						rcon.WritePacket(conn, rcon.Packet{ID: p.ID, Type: rcon.TypeCommand})
+					case rcon.TypeCommand:
+						rcon.WritePacket(conn, rcon.Packet{ID: p.ID, Type: rcon.TypeResponse, Body: answers[p.Body]})
+					default:
+						rcon.WritePacket(conn, rcon.Packet{ID: p.ID, Type: rcon.TypeResponse, Body: fmt.Sprintf("Unknown request %x", p.Type)})
+					}
+				}
+			}()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	props := map[string]string{"enable-rcon": "true", "rcon.password": "secret", "rcon.port": port}
+	if err := config.SaveProperties(dir, props); err != nil {
+		t.Fatalf("[TEST] SaveProperties failed: %v", err)
+	}
+}
+
+// stdinRecorder fails the test when anything is written to the console.
+type stdinRecorder struct{ t *testing.T }
+
+func (r stdinRecorder) Write(b []byte) (int, error) {
+	r.t.Errorf("[TEST] %q went to stdin, want RCON", b)
+	return len(b), nil
+}
+
+func (r stdinRecorder) Close() error { return nil }
+
+func TestExecCommandRCON(t *testing.T) {
+	dir := t.TempDir()
+	fakeRCON(t, dir, map[string]string{
+		"op Steve":            "Made Steve a server operator",
+		"op Nobody":           "\u00a7cThat player does not exist",
+		"whitelist add Steve": "Player is already whitelisted",
+	})
+	s := &Server{Hub: NewHub(), status: StatusRunning, stdin: stdinRecorder{t}, WorkDir: dir}
+
+	tests := []struct {
+		name     string
+		cmd      string
+		rejected bool
+	}{
+		{"Accepted", "op Steve", false},
+		{"Rejected with color codes", "op Nobody", true},
+		{"Already applied", "whitelist add Steve", false},
+		{"No output", "save-all", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No quiet window to wait for, RCON answers right away
+			start := time.Now()
+			err := s.runChecked(tt.cmd)
+			if rejected := errors.Is(err, ErrCommandRejected); rejected != tt.rejected {
+				t.Errorf("[TEST] runChecked(%q) = %v, want rejected: %v", tt.cmd, err, tt.rejected)
+			}
+			if elapsed := time.Since(start); elapsed >= execQuiet {
+				t.Errorf("[TEST] runChecked(%q) took %s, want less than %s", tt.cmd, elapsed, execQuiet)
+			}
+		})
+	}
+
+	lines, err := s.CommandSync("op Steve")
+	if err != nil || len(lines) != 1 || lines[0] != "Made Steve a server operator" {
+		t.Errorf("[TEST] CommandSync = %q, %v, want: [Made Steve a server operator]", lines, err)
+	}
+}
+
+func TestCommandSyncWithoutRCON(t *testing.T) {
+	s := fakeConsole(t, nil)
+	s.WorkDir = t.TempDir()
+	if _, err := s.CommandSync("list"); !errors.Is(err, ErrNoRCON) {
+		t.Errorf("[TEST] CommandSync without server.properties = %v, want: %v", err, ErrNoRCON)
+	}
+
+	config.SaveProperties(s.WorkDir, map[string]string{"enable-rcon": "false"})
+	if _, err := s.CommandSync("list"); !errors.Is(err, ErrNoRCON) {
+		t.Errorf("[TEST] CommandSync with rcon disabled = %v, want: %v", err, ErrNoRCON)
+	}
+}
+
+// fakeManagement is a management server with an in-memory operator list, it
+// resolves every player but "Nobody". It is enabled in the server.properties
+// of dir.
+func fakeManagement(t *testing.T, dir string) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	ops := []management.Operator{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var req struct {
+				ID     int64             `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+			switch req.Method {
+			case "minecraft:operators/add":
+				var added []management.Operator
+				json.Unmarshal(req.Params[0], &added)
+				for _, op := range added {
+					if op.Player.Name != "Nobody" && !slices.ContainsFunc(ops, func(o management.Operator) bool { return o.Player.Name == op.Player.Name }) {
+						ops = append(ops, op)
+					}
+				}
+				resp["result"] = ops
+			default:
+				resp["error"] = management.RPCError{Code: -32601, Message: "Method not found"}
+			}
+			conn.WriteJSON(resp)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	props := map[string]string{"management-server-enabled": "true", "management-server-host": "127.0.0.1",
+		"management-server-port": port, "management-server-tls-enabled": "false"}
+	if err := config.SaveProperties(dir, props); err != nil {
+		t.Fatalf("[TEST] SaveProperties failed: %v", err)
+	}
+}
+
+func TestPlayerOpsManagement(t *testing.T) {
+	dir := t.TempDir()
+	fakeManagement(t, dir)
+	s := &Server{Hub: NewHub(), status: StatusRunning, stdin: stdinRecorder{t}, WorkDir: dir}
+	t.Cleanup(func() { s.closeManagement(nil) })
+
+	// Same answers as over the console: an operator made again is no error
+	tests := []struct {
+		name     string
+		player   string
+		rejected bool
+	}{
+		{"Accepted", "Steve", false},
+		{"Already applied", "Steve", false},
+		{"Unknown player", "Nobody", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.OpUser(tt.player)
+			if rejected := errors.Is(err, ErrCommandRejected); rejected != tt.rejected || (err != nil && !rejected) {
+				t.Errorf("[TEST] OpUser(%q) = %v, want rejected: %v", tt.player, err, tt.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package minecraft
+
+import (
+	"sync"
+
+	"paperMC_backend/internal/jobs"
+	"paperMC_backend/internal/logparse"
+)
+
+// Kinds of hub messages
+const (
+	KindLog       = "log"       // a console line, see Message.Log
+	KindLifecycle = "lifecycle" // a state change or an exit, see Message.Event
+	KindGame      = "game"      // a game event parsed from the console, see Message.Game
+	KindGap       = "gap"       // messages were skipped, see Message.Dropped and the seq range
+	KindJob       = "job"       // a background job of the server moved on, see Message.Job
+)
+
+// SubscriberBuffer is the default queue length of a subscriber.
+const SubscriberBuffer = 256
+
+// SlowPolicy decides what happens when a subscriber's queue is full.
+type SlowPolicy int
+
+const (
+	// DropWithGap drops messages and delivers one gap marker once the
+	// subscriber catches up.
+	DropWithGap SlowPolicy = iota
+	// Disconnect closes the subscription, the client has to reconnect.
+	Disconnect
+)
+
+// Message is one item delivered to every subscriber of a server.
+type Message struct {
+	Kind    string          `json:"kind"`
+	Log     *LogLine        `json:"log,omitempty"`
+	Event   *Event          `json:"event,omitempty"`
+	Game    *logparse.Event `json:"game,omitempty"`
+	Job     *jobs.Job       `json:"job,omitempty"`
+	Dropped int             `json:"dropped,omitempty"`
+	// Console lines missing from a gap, FromSeq to ToSeq included.
+	// Zero when only events were skipped.
+	FromSeq int64 `json:"from_seq,omitempty"`
+	ToSeq   int64 `json:"to_seq,omitempty"`
+}
+
+// gapMessage describes lines missed between two sequence numbers, both excluded.
+func gapMessage(after, next int64) Message {
+	return Message{Kind: KindGap, Dropped: int(next - after - 1), FromSeq: after + 1, ToSeq: next - 1}
+}
+
+// Hub fans messages out to any number of subscribers. Publish never blocks,
+// each subscriber has its own queue.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscription is one consumer of a Hub.
+type Subscription struct {
+	hub     *Hub
+	ch      chan Message
+	policy  SlowPolicy
+	dropped int     // messages lost since the last gap marker, guarded by hub.mu
+	gap     Message // seq range of the lost lines, guarded by hub.mu
+	closed  bool    // guarded by hub.mu
+}
+
+// drop records a lost message, must be called with hub.mu held.
+func (sub *Subscription) drop(msg Message) {
+	sub.dropped++
+	if msg.Kind != KindLog {
+		return
+	}
+	if sub.gap.FromSeq == 0 {
+		sub.gap.FromSeq = msg.Log.Seq
+	}
+	sub.gap.ToSeq = msg.Log.Seq
+}
+
+// Subscribe registers a consumer with a queue of buffer messages.
+func (h *Hub) Subscribe(buffer int, policy SlowPolicy) *Subscription {
+	if buffer <= 0 {
+		buffer = SubscriberBuffer
+	}
+	sub := &Subscription{hub: h, ch: make(chan Message, buffer), policy: policy}
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// C delivers the messages. It is closed when the subscription ends,
+// either by Close or because a Disconnect subscriber fell behind.
+func (sub *Subscription) C() <-chan Message {
+	return sub.ch
+}
+
+func (sub *Subscription) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	sub.hub.remove(sub)
+}
+
+// Publish delivers msg to every subscriber without waiting for any of them.
+func (h *Hub) Publish(msg Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		// 1. Tell a subscriber that caught up what it missed
+		if sub.dropped > 0 {
+			gap := Message{Kind: KindGap, Dropped: sub.dropped, FromSeq: sub.gap.FromSeq, ToSeq: sub.gap.ToSeq}
+			select {
+			case sub.ch <- gap:
+				sub.dropped, sub.gap = 0, Message{}
+			default:
+				sub.drop(msg)
+				continue
+			}
+		}
+
+		// 2. Queue the message or apply the slow consumer policy
+		select {
+		case sub.ch <- msg:
+		default:
+			if sub.policy == Disconnect {
+				h.remove(sub)
+				continue
+			}
+			sub.drop(msg)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,149 @@
+package minecraft
+
+import (
+	"fmt"
+	"testing"
+)
+
+func logMessage(text string) Message {
+	return Message{Kind: KindLog, Log: &LogLine{Stream: StreamStdout, Text: text}}
+}
+
+func TestHubFanOut(t *testing.T) {
+	hub := NewHub()
+	a := hub.Subscribe(4, DropWithGap)
+	b := hub.Subscribe(4, DropWithGap)
+
+	hub.Publish(logMessage("one"))
+	hub.Publish(logMessage("two"))
+
+	for name, sub := range map[string]*Subscription{"a": a, "b": b} {
+		for _, want := range []string{"one", "two"} {
+			msg := <-sub.C()
+			if msg.Kind != KindLog || msg.Log.Text != want {
+				t.Errorf("[TEST] subscriber %s got %+v, want: %q", name, msg, want)
+			}
+		}
+	}
+
+	a.Close()
+	if _, open := <-a.C(); open {
+		t.Errorf("[TEST] channel still open after Close")
+	}
+	if got := hub.Subscribers(); got != 1 {
+		t.Errorf("[TEST] Subscribers() = %d, want: 1", got)
+	}
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SlowPolicy
+		// Expected messages after 5 publishes into a queue of 2, draining, then one more publish
+		want   []string
+		closed bool
+	}{
+		{"Gap marker", DropWithGap, []string{"1", "2", "gap:3", "6"}, false},
+		{"Disconnect", Disconnect, []string{"1", "2"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			sub := hub.Subscribe(2, tt.policy)
+			for _, text := range []string{"1", "2", "3", "4", "5"} {
+				hub.Publish(logMessage(text))
+			}
+
+			var got []string
+			drain := func() {
+				for {
+					select {
+					case msg, open := <-sub.C():
+						if !open {
+							return
+						}
+						if msg.Kind == KindGap {
+							got = append(got, fmt.Sprintf("gap:%d", msg.Dropped))
+						} else {
+							got = append(got, msg.Log.Text)
+						}
+					default:
+						return
+					}
+				}
+			}
+			drain()
+			hub.Publish(logMessage("6"))
+			drain()
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("[TEST] got %v, want: %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("[TEST] got %v, want: %v", got, tt.want)
+					break
+				}
+			}
+			if closed := hub.Subscribers() == 0; closed != tt.closed {
+				t.Errorf("[TEST] subscription closed = %v, want: %v", closed, tt.closed)
+			}
+		})
+	}
+}
+
+func TestServerResume(t *testing.T) {
+	s := &Server{Hub: NewHub()}
+	for i := 1; i <= historyLines+10; i++ {
+		s.emit(LogLine{Stream: StreamStdout, Text: fmt.Sprint(i)})
+	}
+	// The history holds lines 11 to historyLines+10
+	last := int64(historyLines + 10)
+
+	tests := []struct {
+		name      string
+		cursor    int64
+		wantFirst int64 // seq of the first replayed line, 0 for none
+		wantGap   [2]int64
+	}{
+		{"Fresh client", 0, 11, [2]int64{}},
+		{"Recent cursor", last - 3, last - 2, [2]int64{}},
+		{"Up to date", last, 0, [2]int64{}},
+		{"Aged out", 5, 11, [2]int64{6, 10}},
+		{"Unknown cursor", last + 100, 11, [2]int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gap, history, sub := s.Resume(tt.cursor, 4, DropWithGap)
+			defer sub.Close()
+
+			var first int64
+			if len(history) > 0 {
+				first = history[0].Seq
+			}
+			if first != tt.wantFirst {
+				t.Errorf("[TEST] first replayed seq = %d, want: %d", first, tt.wantFirst)
+			}
+			if got := [2]int64{gap.FromSeq, gap.ToSeq}; got != tt.wantGap {
+				t.Errorf("[TEST] gap = %v, want: %v", got, tt.wantGap)
+			}
+			if tt.wantGap != ([2]int64{}) && gap.Kind != KindGap {
+				t.Errorf("[TEST] gap kind = %q, want: %q", gap.Kind, KindGap)
+			}
+		})
+	}
+
+	// Lines dropped for a slow subscriber are reported by seq
+	_, _, sub := s.Resume(last, 1, DropWithGap)
+	defer sub.Close()
+	for i := 0; i < 3; i++ {
+		s.emit(LogLine{Stream: StreamStdout, Text: "live"})
+	}
+	<-sub.C()
+	s.emit(LogLine{Stream: StreamStdout, Text: "live"})
+	if gap := <-sub.C(); gap.Kind != KindGap || gap.FromSeq != last+2 || gap.ToSeq != last+3 {
+		t.Errorf("[TEST] live gap = %+v, want: lines %d to %d", gap, last+2, last+3)
+	}
+}
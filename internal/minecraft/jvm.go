@@ -0,0 +1,233 @@
+package minecraft
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"paperMC_backend/internal/database"
+
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+const DefaultProfile = "default"
+
+// aikarLargeHeap is the heap size from which Aikar recommends the "large" G1 values.
+const aikarLargeHeap = 12 << 30
+
+// BuiltinProfiles are read-only and always available.
+var BuiltinProfiles = []database.JVMProfile{
+	{
+		Name:        DefaultProfile,
+		Description: "No extra flags, JVM defaults",
+		Flags:       []string{},
+		Builtin:     true,
+	},
+	{
+		Name:        "aikar",
+		Description: "Aikar's G1GC flags (https://docs.papermc.io/paper/aikars-flags), tuned automatically for heaps of 12G and more",
+		Flags: []string{
+			"-XX:+UseG1GC",
+			"-XX:+ParallelRefProcEnabled",
+			"-XX:MaxGCPauseMillis=200",
+			"-XX:+UnlockExperimentalVMOptions",
+			"-XX:+DisableExplicitGC",
+			"-XX:+AlwaysPreTouch",
+			"-XX:G1NewSizePercent=30",
+			"-XX:G1MaxNewSizePercent=40",
+			"-XX:G1HeapRegionSize=8M",
+			"-XX:G1ReservePercent=20",
+			"-XX:G1HeapWastePercent=5",
+			"-XX:G1MixedGCCountTarget=4",
+			"-XX:InitiatingHeapOccupancyPercent=15",
+			"-XX:G1MixedGCLiveThresholdPercent=90",
+			"-XX:G1RSetUpdatingPauseTimePercent=5",
+			"-XX:SurvivorRatio=32",
+			"-XX:+PerfDisableSharedMem",
+			"-XX:MaxTenuringThreshold=1",
+			"-Dusing.aikars.flags=https://mcflags.emc.gs",
+			"-Daikars.new.flags=true",
+		},
+		Builtin: true,
+	},
+	{
+		Name:        "zgc-generational",
+		Description: "Generational ZGC, low pause times on Java 21+ (the only ZGC mode from Java 23)",
+		Flags: []string{
+			"-XX:+UseZGC",
+			"-XX:+ZGenerational",
+			"-XX:+AlwaysPreTouch",
+			"-XX:+DisableExplicitGC",
+			"-XX:+PerfDisableSharedMem",
+		},
+		Builtin: true,
+	},
+}
+
+// zgcGenerationalFlag selects generational ZGC on Java 21 and 22. It is
+// deprecated in 23 and obsolete from 24, where it warns on every boot.
+const zgcGenerationalFlag = "-XX:+ZGenerational"
+
+// forbiddenFlags change what gets executed, a profile only tunes the JVM.
+var forbiddenFlags = []string{"-cp", "-classpath", "--class-path", "-XX:OnError", "-XX:OnOutOfMemoryError"}
+
+// aikarLargeFlags replaces the G1 sizing values for big heaps.
+var aikarLargeFlags = map[string]string{
+	"-XX:G1NewSizePercent":               "40",
+	"-XX:G1MaxNewSizePercent":            "50",
+	"-XX:G1HeapRegionSize":               "16M",
+	"-XX:G1ReservePercent":               "15",
+	"-XX:InitiatingHeapOccupancyPercent": "20",
+}
+
+func builtinProfile(name string) (database.JVMProfile, bool) {
+	for _, p := range BuiltinProfiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return database.JVMProfile{}, false
+}
+
+// ListProfiles returns the built-in profiles followed by the custom ones.
+func ListProfiles(store database.Store) ([]database.JVMProfile, error) {
+	custom, err := store.GetJVMProfiles()
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]database.JVMProfile{}, BuiltinProfiles...), custom...), nil
+}
+
+func LookupProfile(store database.Store, name string) (database.JVMProfile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+	if p, ok := builtinProfile(name); ok {
+		return p, nil
+	}
+	p, err := store.GetJVMProfile(name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.JVMProfile{}, fmt.Errorf("unknown JVM profile %q", name)
+	}
+	if err != nil {
+		return database.JVMProfile{}, err
+	}
+	return *p, nil
+}
+
+// ValidateProfile checks a custom profile before it is saved.
+func ValidateProfile(profile database.JVMProfile) error {
+	if profile.Name == "" {
+		return errors.New("profile name cannot be empty")
+	}
+	if _, ok := builtinProfile(profile.Name); ok {
+		return fmt.Errorf("%q is a built-in profile", profile.Name)
+	}
+	for _, flag := range profile.Flags {
+		// Heap and jar are managed by the backend
+		if strings.HasPrefix(flag, "-Xmx") || strings.HasPrefix(flag, "-Xms") || flag == "-jar" {
+			return fmt.Errorf("flag %q is managed by the server RAM/jar settings", flag)
+		}
+		if !strings.HasPrefix(flag, "-") {
+			return fmt.Errorf("flag %q must start with '-'", flag)
+		}
+		key, _, _ := strings.Cut(flag, "=")
+		for _, forbidden := range forbiddenFlags {
+			if key == forbidden {
+				return fmt.Errorf("flag %q is not allowed in a profile", flag)
+			}
+		}
+	}
+	return nil
+}
+
+// ProfileFlags returns the flags of a profile for the given heap size and
+// Java release (0 when unknown).
+func ProfileFlags(profile database.JVMProfile, heap uint64, javaMajor int) []string {
+	flags := make([]string, 0, len(profile.Flags))
+	for _, flag := range profile.Flags {
+		if flag == zgcGenerationalFlag && javaMajor >= 23 {
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	if !profile.Builtin || profile.Name != "aikar" || heap < aikarLargeHeap {
+		return flags
+	}
+	for i, flag := range flags {
+		key, _, _ := strings.Cut(flag, "=")
+		if value, ok := aikarLargeFlags[key]; ok {
+			flags[i] = key + "=" + value
+		}
+	}
+	return flags
+}
+
+// BuildCommandLine assembles the full java invocation.
+func BuildCommandLine(java, ram string, flags []string, jarFile string, args []string) []string {
+	line := []string{java, "-Xms" + ram, "-Xmx" + ram}
+	line = append(line, flags...)
+	line = append(line, "-jar", jarFile)
+	return append(line, args...)
+}
+
+// ParseMemory parses a JVM memory size ("512M", "8G", "1048576") into bytes.
+func ParseMemory(size string) (uint64, error) {
+	size = strings.TrimSpace(size)
+	if size == "" {
+		return 0, errors.New("memory size cannot be empty")
+	}
+
+	multiplier := uint64(1)
+	switch unicode.ToLower(rune(size[len(size)-1])) {
+	case 'k':
+		multiplier = 1 << 10
+	case 'm':
+		multiplier = 1 << 20
+	case 'g':
+		multiplier = 1 << 30
+	case 't':
+		multiplier = 1 << 40
+	}
+	if multiplier != 1 {
+		size = size[:len(size)-1]
+	}
+
+	n, err := strconv.ParseUint(size, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid memory size %q", size)
+	}
+	return n * multiplier, nil
+}
+
+// ValidateHeap compares the heap with the host memory. It returns an error if
+// the heap can never fit and warnings if it leaves little room for the OS.
+func ValidateHeap(ram string) (uint64, []string, error) {
+	heap, err := ParseMemory(ram)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		// Can't tell, don't block the start
+		return heap, []string{"could not read host memory: " + err.Error()}, nil
+	}
+
+	if heap >= vm.Total {
+		return heap, nil, fmt.Errorf("heap %s exceeds host memory (%d MiB)", ram, vm.Total>>20)
+	}
+
+	var warnings []string
+	// The JVM needs memory beyond the heap (metaspace, threads, direct buffers)
+	if heap > vm.Total/100*85 {
+		warnings = append(warnings, fmt.Sprintf("heap %s is more than 85%% of host memory (%d MiB)", ram, vm.Total>>20))
+	}
+	if heap > vm.Available {
+		warnings = append(warnings, fmt.Sprintf("heap %s is more than currently available memory (%d MiB)", ram, vm.Available>>20))
+	}
+	return heap, warnings, nil
+}
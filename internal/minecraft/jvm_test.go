@@ -0,0 +1,95 @@
+package minecraft
+
+import (
+	"strings"
+	"testing"
+
+	"paperMC_backend/internal/database"
+)
+
+func TestBuildCommandLine(t *testing.T) {
+	zgc, _ := builtinProfile("zgc-generational")
+	aikar, _ := builtinProfile("aikar")
+
+	tests := []struct {
+		name      string
+		profile   database.JVMProfile
+		heap      uint64
+		javaMajor int
+		want      string
+	}{
+		{"Default profile", BuiltinProfiles[0], 4 << 30, 21, "java -Xms4G -Xmx4G -jar server.jar nogui"},
+		{"ZGC keeps ZGenerational on 21", zgc, 4 << 30, 21,
+			"java -Xms4G -Xmx4G -XX:+UseZGC -XX:+ZGenerational -XX:+AlwaysPreTouch -XX:+DisableExplicitGC -XX:+PerfDisableSharedMem -jar server.jar nogui"},
+		{"ZGC drops ZGenerational on 24", zgc, 4 << 30, 24,
+			"java -Xms4G -Xmx4G -XX:+UseZGC -XX:+AlwaysPreTouch -XX:+DisableExplicitGC -XX:+PerfDisableSharedMem -jar server.jar nogui"},
+		{"Custom flags in order", database.JVMProfile{Name: "mine", Flags: []string{"-XX:+UseShenandoahGC", "-Dfile.encoding=UTF-8"}}, 4 << 30, 21,
+			"java -Xms4G -Xmx4G -XX:+UseShenandoahGC -Dfile.encoding=UTF-8 -jar server.jar nogui"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := BuildCommandLine("java", "4G", ProfileFlags(tt.profile, tt.heap, tt.javaMajor), "server.jar", []string{"nogui"})
+			if got := strings.Join(line, " "); got != tt.want {
+				t.Errorf("[TEST] command line = %q, want: %q", got, tt.want)
+			}
+		})
+	}
+
+	// Aikar's G1 sizing switches at 12G
+	small := strings.Join(ProfileFlags(aikar, 8<<30, 21), " ")
+	large := strings.Join(ProfileFlags(aikar, 16<<30, 21), " ")
+	if !strings.Contains(small, "-XX:G1HeapRegionSize=8M") || !strings.Contains(large, "-XX:G1HeapRegionSize=16M") {
+		t.Errorf("[TEST] aikar flags not tuned for the heap: %q / %q", small, large)
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		wantErr bool
+	}{
+		{"GC tuning", []string{"-XX:+UseG1GC", "-XX:MaxGCPauseMillis=200"}, false},
+		{"Heap is managed", []string{"-Xmx4G"}, true},
+		{"Jar is managed", []string{"-jar"}, true},
+		{"Not a flag", []string{"plugin.jar"}, true},
+		{"Classpath", []string{"-cp"}, true},
+		{"Long classpath", []string{"-classpath"}, true},
+		{"OnError runs a command", []string{"-XX:OnError=rm -rf /"}, true},
+		{"OnOutOfMemoryError runs a command", []string{"-XX:OnOutOfMemoryError=sh evil.sh"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProfile(database.JVMProfile{Name: "custom", Flags: tt.flags})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("[TEST] ValidateProfile(%v) err = %v, want error: %v", tt.flags, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateHeap(t *testing.T) {
+	tests := []struct {
+		ram      string
+		wantHeap uint64
+		wantErr  bool
+	}{
+		{"512M", 512 << 20, false},
+		{"1048576", 1 << 20, false},
+		{"2g", 2 << 30, false},
+		{"", 0, true},
+		{"lots", 0, true},
+		{"0M", 0, true},
+		{"4096T", 0, true}, // more than any host
+	}
+	for _, tt := range tests {
+		heap, _, err := ValidateHeap(tt.ram)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("[TEST] ValidateHeap(%q) err = %v, want error: %v", tt.ram, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && heap != tt.wantHeap {
+			t.Errorf("[TEST] ValidateHeap(%q) = %d, want: %d", tt.ram, heap, tt.wantHeap)
+		}
+	}
+}
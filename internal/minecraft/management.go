@@ -0,0 +1,134 @@
+package minecraft
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/config"
+	"paperMC_backend/internal/logparse"
+	"paperMC_backend/internal/management"
+)
+
+// managementTimeout bounds connecting to the management server and every call.
+const managementTimeout = 10 * time.Second
+
+var ErrNoManagement = errors.New("the management server is not enabled in server.properties")
+
+// managementClient returns the open management connection or dials one with
+// the settings of server.properties (Minecraft 1.21.9+).
+func (s *Server) managementClient() (*management.Client, error) {
+	s.mu.Lock()
+	status, workDir := s.status, s.WorkDir
+	s.mu.Unlock()
+	if status != StatusRunning {
+		return nil, ErrNoManagement
+	}
+
+	s.mgmtMu.Lock()
+	defer s.mgmtMu.Unlock()
+	if s.mgmt != nil {
+		return s.mgmt, nil
+	}
+
+	props, err := config.LoadProperties(workDir)
+	if err != nil {
+		return nil, err
+	}
+	port := props["management-server-port"]
+	if props["management-server-enabled"] != "true" || port == "" || port == "0" {
+		return nil, ErrNoManagement
+	}
+	host := props["management-server-host"]
+	if host == "" {
+		host = "localhost"
+	}
+	url := "ws://" + net.JoinHostPort(host, port)
+	opts := management.Options{Secret: props["management-server-secret"]}
+	if props["management-server-tls-enabled"] != "false" {
+		url = "wss://" + net.JoinHostPort(host, port)
+		// The keystore is usually self-signed, we only ever talk to our own server
+		opts.TLS = &tls.Config{InsecureSkipVerify: isLoopback(host)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), managementTimeout)
+	defer cancel()
+	client, err := management.Dial(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.mgmt = client
+	go s.watchManagement(client)
+	return client, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// watchManagement applies the player notifications until the connection closes.
+func (s *Server) watchManagement(client *management.Client) {
+	for n := range client.Notifications() {
+		var kind string
+		switch n.Method {
+		case management.NotifyPlayerJoined:
+			kind = logparse.TypeJoin
+		case management.NotifyPlayerLeft:
+			kind = logparse.TypeLeave
+		default:
+			continue
+		}
+		s.mu.Lock()
+		for _, player := range management.PlayersOf(n) {
+			s.updateSession(logparse.Event{Type: kind, Player: player.Name})
+		}
+		s.mu.Unlock()
+	}
+	s.closeManagement(client)
+}
+
+// closeManagement closes client, or the open connection when client is nil.
+func (s *Server) closeManagement(client *management.Client) {
+	s.mgmtMu.Lock()
+	defer s.mgmtMu.Unlock()
+	if s.mgmt == nil || (client != nil && client != s.mgmt) {
+		return
+	}
+	s.mgmt.Close()
+	s.mgmt = nil
+}
+
+// manage runs a player operation over the management protocol. handled is
+// false when the server has no management connection, the caller then falls
+// back to a console command.
+func (s *Server) manage(op func(ctx context.Context, c *management.Client) error) (handled bool, err error) {
+	client, err := s.managementClient()
+	if err != nil {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), managementTimeout)
+	defer cancel()
+	err = op(ctx, client)
+	if errors.Is(err, management.ErrClosed) {
+		s.closeManagement(client)
+	}
+	return true, err
+}
+
+// listed reports whether name is in the list a management call returned,
+// the server leaves out players it could not resolve.
+func listed(name string, players []management.Player) bool {
+	for _, p := range players {
+		if strings.EqualFold(p.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,454 @@
+package minecraft
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
+)
+
+// Settings written by older versions, before instances existed. They are
+// imported into the default instance the first time the manager loads.
+const (
+	legacySettingJVMProfile = "jvm_profile"
+	legacySettingServerArgs = "server_args"
+	legacySettingJavaPath   = "java_path"
+)
+
+var instanceIDRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ServerOptions are shared by every instance.
+type ServerOptions struct {
+	Restart        RestartPolicy
+	StartupTimeout time.Duration
+	StopTimeout    time.Duration
+	TermTimeout    time.Duration
+	Detach         bool // servers keep running when the backend exits, see Server.Adopt
+	RCON           bool // enable rcon in server.properties, see Server.ExecCommand
+	Retention      LogRetention
+}
+
+// InstanceInfo is an instance with its live status.
+type InstanceInfo struct {
+	database.Instance
+	Status Status `json:"status"`
+}
+
+// ServerManager owns every Minecraft server instance.
+type ServerManager struct {
+	store     database.Store
+	java      *java.Registry
+	options   ServerOptions
+	defaultID string
+
+	mu        sync.RWMutex
+	servers   map[string]*Server
+	instances map[string]database.Instance
+
+	done      chan struct{} // closed by Close, stops pruneLogs
+	closeOnce sync.Once
+}
+
+func NewServerManager(store database.Store, runtimes *java.Registry, options ServerOptions) *ServerManager {
+	return &ServerManager{
+		store:     store,
+		java:      runtimes,
+		options:   options,
+		servers:   make(map[string]*Server),
+		instances: make(map[string]database.Instance),
+		done:      make(chan struct{}),
+	}
+}
+
+// Close stops the log pruning and the archives. The servers are left alone,
+// stop them first with StopAll.
+func (m *ServerManager) Close() {
+	m.closeOnce.Do(func() { close(m.done) })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, server := range m.servers {
+		stopArchive(server)
+	}
+}
+
+// Load builds a Server for every stored instance. When the database has no
+// instance yet, seed is created (and becomes the default).
+func (m *ServerManager) Load(seed database.Instance) error {
+	instances, err := m.store.GetInstances()
+	if err != nil {
+		return err
+	}
+
+	if len(instances) == 0 {
+		if err := m.importLegacySettings(&seed); err != nil {
+			return err
+		}
+		if err := m.store.CreateInstance(&seed); err != nil {
+			return err
+		}
+		// Read it back for the stored created_at
+		if instances, err = m.store.GetInstances(); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.defaultID = seed.ID
+	for _, instance := range instances {
+		server, err := m.newServer(instance)
+		if err != nil {
+			return fmt.Errorf("instance %s: %w", instance.ID, err)
+		}
+		m.servers[instance.ID] = server
+		m.instances[instance.ID] = instance
+	}
+	if _, ok := m.servers[m.defaultID]; !ok {
+		// The seed ID changed, fall back to the oldest instance
+		m.defaultID = instances[0].ID
+	}
+
+	go m.pruneLogs()
+
+	// Pick up the servers a previous backend left running
+	for _, server := range m.servers {
+		adopted, err := server.Adopt()
+		if err != nil {
+			server.Broadcast("[ERROR] Could not re-attach to the running server: " + err.Error())
+			continue
+		}
+		if adopted {
+			server.Broadcast(fmt.Sprintf("[System] Re-attached to the running server (PID %d)", server.PID()))
+		}
+	}
+	return nil
+}
+
+func (m *ServerManager) importLegacySettings(seed *database.Instance) error {
+	if value, err := m.store.GetSetting(legacySettingJVMProfile); err == nil {
+		seed.JVMProfile = value
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if value, err := m.store.GetSetting(legacySettingJavaPath); err == nil {
+		seed.JavaPath = value
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if value, err := m.store.GetSetting(legacySettingServerArgs); err == nil {
+		if err := json.Unmarshal([]byte(value), &seed.ServerArgs); err != nil {
+			return fmt.Errorf("invalid %s setting: %w", legacySettingServerArgs, err)
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	return nil
+}
+
+// newServer must be called with m.mu held.
+func (m *ServerManager) newServer(instance database.Instance) (*Server, error) {
+	server := NewServer(instance.WorkDir, instance.JarFile, instance.RAM, m.store)
+	server.ID = instance.ID
+	server.Port = instance.Port
+	server.Restart = m.options.Restart
+	server.StartupTimeout = m.options.StartupTimeout
+	server.StopTimeout = m.options.StopTimeout
+	server.TermTimeout = m.options.TermTimeout
+	server.Detach = m.options.Detach
+	server.RCON = m.options.RCON
+	if err := m.configure(server, instance); err != nil {
+		return nil, err
+	}
+
+	// Sequence numbers continue where the archive stopped
+	seq, err := m.store.LastConsoleSeq(instance.ID)
+	if err != nil {
+		return nil, err
+	}
+	server.seq = seq
+	m.startArchive(server)
+	return server, nil
+}
+
+// configure applies the launch settings of an instance to its server.
+func (m *ServerManager) configure(server *Server, instance database.Instance) error {
+	profile, err := LookupProfile(m.store, instance.JVMProfile)
+	if err != nil {
+		return err
+	}
+	rt, err := m.java.Resolve(instance.JavaPath)
+	if err != nil {
+		// Keep the instance usable, Start reports the problem
+		rt = java.Runtime{Path: "java"}
+		if instance.JavaPath != "" {
+			rt.Path = instance.JavaPath
+		}
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.JarFile = instance.JarFile
+	server.RAM = instance.RAM
+	server.Port = instance.Port
+	server.Profile = profile
+	server.Args = instance.ServerArgs
+	server.Java = rt
+	return nil
+}
+
+// Get returns the server with the given ID, an empty ID means the default one.
+func (m *ServerManager) Get(id string) (*Server, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if id == "" {
+		id = m.defaultID
+	}
+	server, ok := m.servers[id]
+	return server, ok
+}
+
+func (m *ServerManager) DefaultID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.defaultID
+}
+
+func (m *ServerManager) Instance(id string) (database.Instance, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if id == "" {
+		id = m.defaultID
+	}
+	instance, ok := m.instances[id]
+	return instance, ok
+}
+
+func (m *ServerManager) List() []InstanceInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]InstanceInfo, 0, len(m.instances))
+	for id, instance := range m.instances {
+		list = append(list, InstanceInfo{Instance: instance, Status: m.servers[id].GetStatus()})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Servers returns every server, for operations that touch all of them.
+func (m *ServerManager) Servers() []*Server {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]*Server, 0, len(m.servers))
+	for _, server := range m.servers {
+		list = append(list, server)
+	}
+	return list
+}
+
+// Create validates and persists a new instance. The work dir is created if needed.
+func (m *ServerManager) Create(instance database.Instance) (*Server, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !instanceIDRegex.MatchString(instance.ID) {
+		return nil, errors.New("id must be 1-32 lowercase letters, digits, '-' or '_'")
+	}
+	if _, exists := m.instances[instance.ID]; exists {
+		return nil, fmt.Errorf("server %q already exists", instance.ID)
+	}
+	if err := m.validate(instance); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(instance.WorkDir, 0755); err != nil {
+		return nil, err
+	}
+
+	server, err := m.newServer(instance)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.CreateInstance(&instance); err != nil {
+		stopArchive(server)
+		return nil, err
+	}
+	// Keep what the database stored, List sorts on created_at
+	stored, err := m.store.GetInstance(instance.ID)
+	if err != nil {
+		stopArchive(server)
+		m.store.DeleteInstance(instance.ID)
+		return nil, err
+	}
+	instance = *stored
+	m.servers[instance.ID] = server
+	m.instances[instance.ID] = instance
+	return server, nil
+}
+
+// Update changes the launch settings, they apply from the next start.
+// The work dir can only change while the server is stopped.
+func (m *ServerManager) Update(instance database.Instance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.instances[instance.ID]
+	if !ok {
+		return ErrServerNotFound
+	}
+	server := m.servers[instance.ID]
+	if err := m.validate(instance); err != nil {
+		return err
+	}
+	if instance.WorkDir != current.WorkDir {
+		if status := server.GetStatus(); status != StatusStopped && status != StatusCrashed {
+			return errors.New("stop the server before moving its work dir")
+		}
+	}
+
+	if err := m.store.UpdateInstance(&instance); err != nil {
+		return err
+	}
+	if err := m.configure(server, instance); err != nil {
+		return err
+	}
+	server.mu.Lock()
+	server.WorkDir = instance.WorkDir
+	server.mu.Unlock()
+
+	instance.CreatedAt = current.CreatedAt
+	m.instances[instance.ID] = instance
+	return nil
+}
+
+// Delete removes a stopped instance. Its files are only removed when asked for.
+func (m *ServerManager) Delete(id string, removeFiles bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	instance, ok := m.instances[id]
+	if !ok {
+		return ErrServerNotFound
+	}
+	if id == m.defaultID {
+		return errors.New("the default server cannot be deleted")
+	}
+	// A crashed server may be waiting for its automatic restart
+	if err := m.servers[id].retire(); err != nil {
+		return err
+	}
+
+	if err := m.store.DeleteInstance(id); err != nil {
+		return err
+	}
+	stopArchive(m.servers[id])
+	delete(m.servers, id)
+	delete(m.instances, id)
+
+	if removeFiles {
+		return os.RemoveAll(instance.WorkDir)
+	}
+	return nil
+}
+
+// RefreshProfile re-applies an edited profile to the servers using it.
+func (m *ServerManager) RefreshProfile(profile database.JVMProfile) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for id, instance := range m.instances {
+		if instance.JVMProfile != profile.Name {
+			continue
+		}
+		server := m.servers[id]
+		server.mu.Lock()
+		server.Profile = profile
+		server.mu.Unlock()
+	}
+}
+
+// ProfileInUse reports the instances using a JVM profile.
+func (m *ServerManager) ProfileInUse(name string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []string
+	for id, instance := range m.instances {
+		if instance.JVMProfile == name {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// StopAll stops every running server in parallel.
+func (m *ServerManager) StopAll(grace time.Duration) map[string]error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[string]error)
+
+	for _, server := range m.Servers() {
+		status := server.GetStatus()
+		if status == StatusStopped || status == StatusCrashed {
+			continue
+		}
+		wg.Add(1)
+		go func(server *Server) {
+			defer wg.Done()
+			stage, err := server.StopWithTimeout(grace)
+			if err == nil {
+				server.Broadcast(fmt.Sprintf("[System] Server stopped (%s)", stage))
+			}
+			mu.Lock()
+			results[server.ID] = err
+			mu.Unlock()
+		}(server)
+	}
+	wg.Wait()
+	return results
+}
+
+var ErrServerNotFound = errors.New("server not found")
+
+// validate must be called with m.mu held.
+func (m *ServerManager) validate(instance database.Instance) error {
+	if instance.Name == "" || instance.WorkDir == "" || instance.JarFile == "" {
+		return errors.New("name, work_dir and jar_file are required")
+	}
+	if _, err := ParseMemory(instance.RAM); err != nil {
+		return err
+	}
+	if instance.Port < 0 || instance.Port > 65535 {
+		return fmt.Errorf("invalid port %d", instance.Port)
+	}
+	if _, err := LookupProfile(m.store, instance.JVMProfile); err != nil {
+		return err
+	}
+	for id, other := range m.instances {
+		if id == instance.ID {
+			continue
+		}
+		if instance.Port != 0 && other.Port == instance.Port {
+			return fmt.Errorf("port %d is already used by %s", instance.Port, id)
+		}
+		if other.WorkDir == instance.WorkDir {
+			return fmt.Errorf("work dir %s is already used by %s", instance.WorkDir, id)
+		}
+	}
+	return nil
+}
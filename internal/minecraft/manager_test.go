@@ -0,0 +1,231 @@
+package minecraft
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
+)
+
+func newTestManager(t *testing.T) (*ServerManager, *database.SQLiteStore) {
+	t.Helper()
+	store, err := database.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("[TEST] NewSQLiteStore error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	m := NewServerManager(store, java.NewRegistry(nil), ServerOptions{Restart: DefaultRestartPolicy()})
+	t.Cleanup(m.Close)
+	return m, store
+}
+
+func testInstance(id, workDir string, port int) database.Instance {
+	return database.Instance{ID: id, Name: id, WorkDir: workDir, JarFile: "server.jar", RAM: "1G", Port: port, ServerArgs: []string{"nogui"}}
+}
+
+func TestServerManagerLoad(t *testing.T) {
+	m, store := newTestManager(t)
+	dir := t.TempDir()
+
+	// Settings of a backend from before instances
+	store.SetSetting(legacySettingJavaPath, "/opt/java/bin/java")
+	store.SetSetting(legacySettingServerArgs, `["nogui","--world-dir","worlds"]`)
+
+	if err := m.Load(testInstance("default", dir, 25565)); err != nil {
+		t.Fatalf("[TEST] Load failed: %v", err)
+	}
+	instance, ok := m.Instance("")
+	if !ok || instance.ID != "default" || m.DefaultID() != "default" {
+		t.Fatalf("[TEST] default instance = %+v, %v, want the seed", instance, ok)
+	}
+	if instance.JavaPath != "/opt/java/bin/java" || !slices.Equal(instance.ServerArgs, []string{"nogui", "--world-dir", "worlds"}) {
+		t.Errorf("[TEST] legacy settings not imported: %+v", instance)
+	}
+	stored, err := store.GetInstance("default")
+	if err != nil || !instance.CreatedAt.Equal(stored.CreatedAt) || instance.CreatedAt.IsZero() {
+		t.Errorf("[TEST] created_at = %v, stored: %+v, %v", instance.CreatedAt, stored, err)
+	}
+
+	// A second start only loads, a changed seed ID falls back to the oldest instance
+	again := NewServerManager(store, java.NewRegistry(nil), ServerOptions{Restart: DefaultRestartPolicy()})
+	defer again.Close()
+	if err := again.Load(testInstance("main", t.TempDir(), 25565)); err != nil {
+		t.Fatalf("[TEST] second Load failed: %v", err)
+	}
+	if again.DefaultID() != "default" || len(again.List()) != 1 {
+		t.Errorf("[TEST] second Load: default %q, %d instances, want: default, 1", again.DefaultID(), len(again.List()))
+	}
+}
+
+func TestServerManagerCreate(t *testing.T) {
+	m, store := newTestManager(t)
+	defaultDir := t.TempDir()
+	if err := m.Load(testInstance("default", defaultDir, 25565)); err != nil {
+		t.Fatalf("[TEST] Load failed: %v", err)
+	}
+	creativeDir := filepath.Join(t.TempDir(), "creative")
+
+	withRAM := testInstance("bad-ram", t.TempDir(), 0)
+	withRAM.RAM = "lots"
+	withProfile := testInstance("bad-profile", t.TempDir(), 0)
+	withProfile.JVMProfile = "missing"
+	withoutJar := testInstance("no-jar", t.TempDir(), 0)
+	withoutJar.JarFile = ""
+
+	// In order, the first case creates "creative"
+	tests := []struct {
+		name     string
+		instance database.Instance
+		wantErr  string
+	}{
+		{"Valid", testInstance("creative", creativeDir, 25566), ""},
+		{"Invalid ID", testInstance("Creative!", t.TempDir(), 0), "id must be"},
+		{"Duplicate ID", testInstance("creative", t.TempDir(), 0), "already exists"},
+		{"Port of the default", testInstance("survival", t.TempDir(), 25565), "port 25565 is already used by default"},
+		{"Port of a new instance", testInstance("survival", t.TempDir(), 25566), "port 25566 is already used by creative"},
+		{"Shared work dir", testInstance("survival", defaultDir, 0), "is already used by default"},
+		{"Invalid port", testInstance("survival", t.TempDir(), 70000), "invalid port"},
+		{"Invalid RAM", withRAM, "invalid"},
+		{"Unknown profile", withProfile, "unknown JVM profile"},
+		{"Missing jar", withoutJar, "required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := m.Create(tt.instance)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("[TEST] Create = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("[TEST] Create failed: %v", err)
+			}
+			if server.ID != tt.instance.ID || server.Port != tt.instance.Port {
+				t.Errorf("[TEST] server = %s on %d, want: %s on %d", server.ID, server.Port, tt.instance.ID, tt.instance.Port)
+			}
+		})
+	}
+
+	if info, err := os.Stat(creativeDir); err != nil || !info.IsDir() {
+		t.Errorf("[TEST] work dir not created: %v", err)
+	}
+	instance, _ := m.Instance("creative")
+	stored, err := store.GetInstance("creative")
+	if err != nil || instance.CreatedAt.IsZero() || !instance.CreatedAt.Equal(stored.CreatedAt) {
+		t.Errorf("[TEST] created_at = %v, stored: %+v, %v", instance.CreatedAt, stored, err)
+	}
+	if list := m.List(); len(list) != 2 {
+		t.Errorf("[TEST] List = %+v, want: default and creative", list)
+	}
+}
+
+func TestServerManagerDelete(t *testing.T) {
+	m, store := newTestManager(t)
+	if err := m.Load(testInstance("default", t.TempDir(), 25565)); err != nil {
+		t.Fatalf("[TEST] Load failed: %v", err)
+	}
+	keptDir, removedDir := t.TempDir(), filepath.Join(t.TempDir(), "survival")
+	for _, instance := range []database.Instance{testInstance("creative", keptDir, 0), testInstance("survival", removedDir, 0)} {
+		if _, err := m.Create(instance); err != nil {
+			t.Fatalf("[TEST] Create %s failed: %v", instance.ID, err)
+		}
+	}
+	store.CreateRestartSchedule(&database.RestartSchedule{InstanceID: "creative", Cron: "0 4 * * *", Enabled: true})
+	store.InsertConsoleLogs([]database.ConsoleLog{{InstanceID: "creative", Seq: 1, Level: "INFO", Source: StreamStdout, Line: "hello"}})
+
+	tests := []struct {
+		name        string
+		id          string
+		removeFiles bool
+		wantErr     string
+		wantDir     string // must still exist, "" to skip
+		goneDir     string // must be removed, "" to skip
+	}{
+		{"Unknown", "nether", false, ErrServerNotFound.Error(), "", ""},
+		{"Default", "default", false, "cannot be deleted", "", ""},
+		{"Keep files", "creative", false, "", keptDir, ""},
+		{"Remove files", "survival", true, "", "", removedDir},
+		{"Already deleted", "creative", false, ErrServerNotFound.Error(), "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.Delete(tt.id, tt.removeFiles)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("[TEST] Delete = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("[TEST] Delete failed: %v", err)
+			}
+			if _, ok := m.Get(tt.id); ok {
+				t.Errorf("[TEST] server %s still managed", tt.id)
+			}
+			if _, err := store.GetInstance(tt.id); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("[TEST] GetInstance(%s) = %v, want: %v", tt.id, err, sql.ErrNoRows)
+			}
+			if _, err := os.Stat(tt.wantDir); tt.wantDir != "" && err != nil {
+				t.Errorf("[TEST] work dir removed: %v", err)
+			}
+			if _, err := os.Stat(tt.goneDir); tt.goneDir != "" && !os.IsNotExist(err) {
+				t.Errorf("[TEST] work dir still there: %v", err)
+			}
+		})
+	}
+
+	// Schedules and archived lines go with the instance
+	schedules, _ := store.GetRestartSchedules()
+	logs, _ := store.SearchConsoleLogs(database.ConsoleLogQuery{InstanceID: "creative", Limit: 10})
+	if len(schedules) != 0 || len(logs) != 0 {
+		t.Errorf("[TEST] %d schedules and %d console lines left, want: 0, 0", len(schedules), len(logs))
+	}
+}
+
+func TestServerManagerDeleteCrashed(t *testing.T) {
+	m, _ := newTestManager(t)
+	if err := m.Load(testInstance("default", t.TempDir(), 25565)); err != nil {
+		t.Fatalf("[TEST] Load failed: %v", err)
+	}
+	if _, err := m.Create(testInstance("creative", t.TempDir(), 0)); err != nil {
+		t.Fatalf("[TEST] Create failed: %v", err)
+	}
+
+	// Every run of the crashing "java" leaves a line in runs
+	fake := fakeJava(t, "echo run >> runs\nexit 1")
+	server, _ := m.Get("creative")
+	server.Java = fake.Java
+	server.WorkDir = fake.WorkDir
+	server.Restart.BaseDelay = 200 * time.Millisecond
+	sub := server.Hub.Subscribe(64, DropWithGap)
+	defer sub.Close()
+	if err := server.Start(); err != nil {
+		t.Fatalf("[TEST] Start failed: %v", err)
+	}
+	lifecycle(t, sub, 1)
+	if status := server.GetStatus(); status != StatusCrashed {
+		t.Fatalf("[TEST] status = %s, want: %s", status, StatusCrashed)
+	}
+
+	// Deleted during the backoff, the restart must not happen
+	if err := m.Delete("creative", false); err != nil {
+		t.Fatalf("[TEST] Delete failed: %v", err)
+	}
+	time.Sleep(3 * server.Restart.BaseDelay)
+	if runs, _ := os.ReadFile(filepath.Join(fake.WorkDir, "runs")); string(runs) != "run\n" {
+		t.Errorf("[TEST] runs = %q, want a single run", runs)
+	}
+	if status := server.GetStatus(); status != StatusCrashed {
+		t.Errorf("[TEST] status after delete = %s, want: %s", status, StatusCrashed)
+	}
+}
@@ -0,0 +1,35 @@
+package minecraft
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"paperMC_backend/internal/config"
+)
+
+// pingTimeout bounds the Server List Ping done for every status request.
+const pingTimeout = 2 * time.Second
+
+// gameAddr returns where the game port can be reached from the backend:
+// server-ip when the server binds to one address, localhost otherwise.
+func (s *Server) gameAddr() string {
+	s.mu.Lock()
+	port, workDir := s.Port, s.WorkDir
+	s.mu.Unlock()
+
+	host := "127.0.0.1"
+	props, err := config.LoadProperties(workDir)
+	if err == nil {
+		if ip := props["server-ip"]; ip != "" {
+			host = ip
+		}
+		if port <= 0 {
+			port, _ = strconv.Atoi(props["server-port"])
+		}
+	}
+	if port <= 0 {
+		port = 25565
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
@@ -1,11 +1,14 @@
 package minecraft
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"paperMC_backend/internal/management"
 )
 
 type Player struct {
@@ -61,7 +64,17 @@ func (s *Server) WhiteListUser(username string) error {
 
 	if err == nil {
 		s.Broadcast(fmt.Sprintf("[System] Whitelisting Java UUID: %s for %s\n", uuid, username))
-		return s.SendCommand("whitelist add " + username)
+		handled, err := s.manage(func(ctx context.Context, c *management.Client) error {
+			list, err := c.AllowlistAdd(ctx, management.PlayersNamed(username)...)
+			if err == nil && !listed(username, list) {
+				err = fmt.Errorf("%w: That player does not exist", ErrCommandRejected)
+			}
+			return err
+		})
+		if handled {
+			return err
+		}
+		return s.runChecked("whitelist add " + username)
 	}
 
 	xuid, err := GetXUID(username)
@@ -73,7 +86,7 @@ func (s *Server) WhiteListUser(username string) error {
 			finalName = FloodgatePrefix + username
 		}
 
-		return s.SendCommand("fwhitelist add " + finalName)
+		return s.runChecked("fwhitelist add " + finalName)
 	}
 
 	s.Broadcast(fmt.Sprintf("[ERROR] User %s not found on Mojang or Xbox live: %s", username, err))
@@ -81,27 +94,75 @@ func (s *Server) WhiteListUser(username string) error {
 	// Failure: Neither API found a user
 }
 func (s *Server) RemoveWhitelist(username string) error {
-	if err := s.SendCommand("whitelist remove " + username); err != nil {
+	handled, err := s.manage(func(ctx context.Context, c *management.Client) error {
+		_, err := c.AllowlistRemove(ctx, management.PlayersNamed(username)...)
+		return err
+	})
+	if handled {
 		return err
 	}
-	return nil
+	return s.runChecked("whitelist remove " + username)
 }
 
 func (s *Server) BanUser(username string, reason string) error {
 	if reason == "" {
 		reason = "Banned by Operator"
 	}
-	return s.SendCommand(fmt.Sprintf("ban %s %s", username, reason))
+	handled, err := s.manage(func(ctx context.Context, c *management.Client) error {
+		bans, err := c.BanAdd(ctx, management.UserBan{Player: management.Player{Name: username}, Reason: reason})
+		if err != nil {
+			return err
+		}
+		for _, ban := range bans {
+			if strings.EqualFold(ban.Player.Name, username) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: That player does not exist", ErrCommandRejected)
+	})
+	if handled {
+		return err
+	}
+	return s.runChecked(fmt.Sprintf("ban %s %s", username, reason))
 }
 
 func (s *Server) UnbanUser(username string) error {
-	return s.SendCommand("pardon " + username)
+	handled, err := s.manage(func(ctx context.Context, c *management.Client) error {
+		_, err := c.BanRemove(ctx, management.PlayersNamed(username)...)
+		return err
+	})
+	if handled {
+		return err
+	}
+	return s.runChecked("pardon " + username)
 }
 
 func (s *Server) OpUser(username string) error {
-	return s.SendCommand("op " + username)
+	handled, err := s.manage(func(ctx context.Context, c *management.Client) error {
+		ops, err := c.OperatorAdd(ctx, management.Operator{Player: management.Player{Name: username}})
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if strings.EqualFold(op.Player.Name, username) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: That player does not exist", ErrCommandRejected)
+	})
+	if handled {
+		return err
+	}
+	return s.runChecked("op " + username)
 }
 
 func (s *Server) DeopUser(username string) error {
-	return s.SendCommand("deop " + username)
+	handled, err := s.manage(func(ctx context.Context, c *management.Client) error {
+		_, err := c.OperatorRemove(ctx, management.PlayersNamed(username)...)
+		return err
+	})
+	if handled {
+		return err
+	}
+	return s.runChecked("deop " + username)
 }
@@ -0,0 +1,132 @@
+package minecraft
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// errAdoptedExit is reported for processes adopted after a backend restart,
+// they are not our children so their exit code can't be read.
+var errAdoptedExit = errors.New("exit code unknown (adopted process)")
+
+// tailInterval is how often the console file and adopted PIDs are polled.
+const tailInterval = 250 * time.Millisecond
+
+// maxConsoleFile is how large .panel/console.log grows before it is emptied.
+const maxConsoleFile = 16 << 20
+
+// javaProcess is the running server, spawned by this backend or adopted
+// from a previous one.
+type javaProcess struct {
+	pid     int
+	os      *os.Process
+	adopted bool
+	exited  chan struct{} // closed once the process is gone
+	err     error         // how it ended, set before exited is closed
+}
+
+// spawned wraps a started command. Its Wait runs here and nowhere else.
+func spawned(cmd *exec.Cmd) *javaProcess {
+	p := &javaProcess{pid: cmd.Process.Pid, os: cmd.Process, exited: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.exited)
+	}()
+	return p
+}
+
+// adopt watches a process started by an earlier backend until it goes away.
+func adopt(proc *process.Process, createTime int64) (*javaProcess, error) {
+	osProc, err := os.FindProcess(int(proc.Pid))
+	if err != nil {
+		return nil, err
+	}
+	p := &javaProcess{pid: int(proc.Pid), os: osProc, adopted: true, exited: make(chan struct{})}
+	go func() {
+		for sameProcess(proc, createTime) {
+			time.Sleep(tailInterval)
+		}
+		p.err = errAdoptedExit
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// sameProcess checks the PID is alive and was not reused by another program.
+func sameProcess(proc *process.Process, createTime int64) bool {
+	running, err := proc.IsRunning()
+	if err != nil || !running {
+		return false
+	}
+	if status, err := proc.Status(); err == nil && len(status) > 0 && status[0] == process.Zombie {
+		return false
+	}
+	created, err := proc.CreateTime()
+	return err == nil && created == createTime
+}
+
+func (p *javaProcess) Signal(sig os.Signal) error {
+	select {
+	case <-p.exited:
+		return os.ErrProcessDone
+	default:
+	}
+	return p.os.Signal(sig)
+}
+
+func (p *javaProcess) Kill() error {
+	return p.Signal(os.Kill)
+}
+
+// logTail reads a console file that is still being written, like tail -f.
+// It returns EOF once the process has exited and the file is drained.
+type logTail struct {
+	file   *os.File
+	exited <-chan struct{}
+	limit  int64 // the file is emptied once read past this size, 0 never
+}
+
+func (t *logTail) Read(b []byte) (int, error) {
+	for {
+		n, err := t.file.Read(b)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		t.rotate()
+		select {
+		case <-t.exited:
+			// One last read for what was flushed right before the exit
+			if n, _ := t.file.Read(b); n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		case <-time.After(tailInterval):
+		}
+	}
+}
+
+// rotate empties the file once everything in it was read and it grew past
+// the limit. java writes with O_APPEND and carries on at the start. The lines
+// read so far are in the history and the archive, one written between the
+// last read and the truncate is lost.
+func (t *logTail) rotate() {
+	if t.limit <= 0 {
+		return
+	}
+	offset, err := t.file.Seek(0, io.SeekCurrent)
+	if err != nil || offset < t.limit {
+		return
+	}
+	if err := os.Truncate(t.file.Name(), 0); err == nil {
+		t.file.Seek(0, io.SeekStart)
+	}
+}
+
+func (t *logTail) Close() error {
+	return t.file.Close()
+}
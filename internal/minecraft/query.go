@@ -0,0 +1,95 @@
+package minecraft
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/config"
+	"paperMC_backend/internal/query"
+)
+
+const (
+	queryInterval = 30 * time.Second // how often OnlinePlayers is checked against the query port
+	queryTimeout  = 2 * time.Second
+)
+
+// queryAddr returns the UDP query address, false when enable-query is off.
+func (s *Server) queryAddr() (string, bool) {
+	game := s.gameAddr()
+	s.mu.Lock()
+	workDir := s.WorkDir
+	s.mu.Unlock()
+
+	props, err := config.LoadProperties(workDir)
+	if err != nil || props["enable-query"] != "true" {
+		return "", false
+	}
+	// query.port defaults to the game port
+	host, port, _ := net.SplitHostPort(game)
+	if p := props["query.port"]; p != "" {
+		port = p
+	}
+	return net.JoinHostPort(host, port), true
+}
+
+// watchQuery reconciles OnlinePlayers with the query port until done is closed.
+func (s *Server) watchQuery(done chan struct{}) {
+	ticker := time.NewTicker(queryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.reconcilePlayers()
+		}
+	}
+}
+
+// reconcilePlayers replaces the player list inferred from the console with
+// the one the server reports, fixing joins or leaves the log missed.
+func (s *Server) reconcilePlayers() {
+	if s.GetStatus() != StatusRunning {
+		return
+	}
+	addr, ok := s.queryAddr()
+	if !ok {
+		return
+	}
+	asked := time.Now()
+	stat, err := query.FullStat(addr, queryTimeout)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.lastQuery = stat
+	online := make(map[string]bool, len(stat.Players))
+	for _, name := range stat.Players {
+		online[name] = true
+	}
+	var added, removed []string
+	for _, name := range stat.Players {
+		if _, known := s.OnlinePlayers[name]; known || s.sessionChanged[name].After(asked) {
+			continue
+		}
+		s.OnlinePlayers[name] = Player{UserName: name, UUID: s.uuidCache[name]}
+		added = append(added, name)
+	}
+	for name := range s.OnlinePlayers {
+		// A join logged while the query was in flight is newer than its answer
+		if online[name] || s.sessionChanged[name].After(asked) {
+			continue
+		}
+		delete(s.OnlinePlayers, name)
+		removed = append(removed, name)
+	}
+	s.mu.Unlock()
+
+	if len(added) > 0 || len(removed) > 0 {
+		s.Broadcast(fmt.Sprintf("[System] Player list corrected from query: joined [%s], left [%s]",
+			strings.Join(added, ", "), strings.Join(removed, ", ")))
+	}
+}
@@ -0,0 +1,129 @@
+package minecraft
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/config"
+	"paperMC_backend/internal/rcon"
+)
+
+// rconTimeout bounds connecting to RCON and every command.
+const rconTimeout = 10 * time.Second
+
+// defaultRCONPort is Minecraft's rcon.port when server.properties has none.
+const defaultRCONPort = "25575"
+
+var ErrNoRCON = errors.New("rcon is not enabled in server.properties")
+
+// applyRCON turns RCON on with a generated password so commands can return
+// their output. With a Port, rcon.port is Port+10 (25565 -> 25575) so several
+// servers don't fight over the default. The caller must hold s.mu.
+func (s *Server) applyRCON() error {
+	if !s.RCON {
+		return nil
+	}
+	props, err := config.LoadProperties(s.WorkDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	changes := make(map[string]string)
+	if props["enable-rcon"] != "true" {
+		changes["enable-rcon"] = "true"
+	}
+	if props["rcon.password"] == "" {
+		secret := make([]byte, 16)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		changes["rcon.password"] = hex.EncodeToString(secret)
+	}
+	if s.Port > 0 && s.Port+10 <= 65535 {
+		if port := strconv.Itoa(s.Port + 10); props["rcon.port"] != port {
+			changes["rcon.port"] = port
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return config.SaveProperties(s.WorkDir, changes)
+}
+
+// CommandSync runs cmd over RCON only and returns its output lines. It fails
+// with ErrNoRCON when server.properties doesn't enable RCON.
+func (s *Server) CommandSync(cmd string) ([]string, error) {
+	client, err := s.rconClient()
+	if err != nil {
+		return nil, err
+	}
+	return s.rconCommand(client, cmd)
+}
+
+// rconCommand runs cmd on client. On error the next call dials again, there
+// is no retry here, the command may have run.
+func (s *Server) rconCommand(client *rcon.Client, cmd string) ([]string, error) {
+	out, err := client.Command(cmd)
+	if err != nil {
+		s.closeRCON(client)
+		return nil, err
+	}
+	return outputLines(out), nil
+}
+
+// rconClient returns the open connection or dials one with the settings of
+// server.properties, which also works for an adopted server.
+func (s *Server) rconClient() (*rcon.Client, error) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	// Paper opens the RCON port once the world is loaded
+	if status != StatusRunning {
+		return nil, fmt.Errorf("server is %s", strings.ToLower(status.String()))
+	}
+
+	s.rconMu.Lock()
+	defer s.rconMu.Unlock()
+	if s.rcon != nil {
+		return s.rcon, nil
+	}
+
+	props, err := config.LoadProperties(s.WorkDir)
+	if os.IsNotExist(err) {
+		return nil, ErrNoRCON
+	}
+	if err != nil {
+		return nil, err
+	}
+	if props["enable-rcon"] != "true" || props["rcon.password"] == "" {
+		return nil, ErrNoRCON
+	}
+	port := props["rcon.port"]
+	if port == "" {
+		port = defaultRCONPort
+	}
+	client, err := rcon.Dial(net.JoinHostPort("127.0.0.1", port), props["rcon.password"], rconTimeout)
+	if err != nil {
+		return nil, err
+	}
+	s.rcon = client
+	return client, nil
+}
+
+// closeRCON closes client, or the open connection when client is nil.
+func (s *Server) closeRCON(client *rcon.Client) {
+	s.rconMu.Lock()
+	defer s.rconMu.Unlock()
+	if s.rcon == nil || (client != nil && client != s.rcon) {
+		return
+	}
+	s.rcon.Close()
+	s.rcon = nil
+}
@@ -13,12 +13,26 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"paperMC_backend/internal/config"
 	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
+	"paperMC_backend/internal/jobs"
+	"paperMC_backend/internal/logparse"
+	"paperMC_backend/internal/management"
+	"paperMC_backend/internal/query"
+	"paperMC_backend/internal/rcon"
+	"paperMC_backend/internal/slp"
+	"paperMC_backend/internal/updater"
 
 	"github.com/shirou/gopsutil/v3/process"
 )
@@ -29,6 +43,8 @@ const (
 	StatusStopped  Status = iota // 0
 	StatusStarting               // 1
 	StatusRunning                // 2
+	StatusStopping               // 3
+	StatusCrashed                // 4
 )
 
 const FloodgatePrefix = "."
@@ -41,6 +57,10 @@ func (s Status) String() string {
 		return "Starting"
 	case StatusRunning:
 		return "Running"
+	case StatusStopping:
+		return "Stopping"
+	case StatusCrashed:
+		return "Crashed"
 	default:
 		return "Unknown"
 	}
@@ -48,38 +68,133 @@ func (s Status) String() string {
 
 type Server struct {
 	// Public fields
+	ID            string
+	Port          int // written to server.properties on start, 0 leaves the file alone
 	WorkDir       string
 	JarFile       string
 	RAM           string
-	Args          []string
-	LogChan       chan string
-	LogHistory    []string
+	Args          []string // server arguments after the jar ("nogui", "--world-dir", ...)
+	Profile       database.JVMProfile
+	Java          java.Runtime
+	Hub           *Hub // console lines and lifecycle events for every connected client
+	LogHistory    []LogLine
 	OnlinePlayers map[string]Player
+	Restart       RestartPolicy
+	// StartupTimeout is how long the server may take to print "Done" before the boot is failed
+	StartupTimeout time.Duration
+	// StopTimeout is the grace period Stop gives the "stop" command before escalating to signals
+	StopTimeout time.Duration
+	// TermTimeout is how long the JVM gets to run its shutdown hooks after SIGTERM before SIGKILL
+	TermTimeout time.Duration
+	// Detach runs java with its console in the work dir so it outlives the backend
+	Detach bool
+	// RCON enables rcon in server.properties on start, see ExecCommand
+	RCON bool
 
 	// Private fields
 	uuidCache map[string]string
+	// sessionChanged is when the console last reported a join or leave of a player
+	sessionChanged map[string]time.Time
+	lastQuery      *query.Stat
+	parser         *logparse.Parser
+	seq            int64 // last LogLine.Seq
+	archive        *Subscription
+	rconMu         sync.Mutex
+	rcon           *rcon.Client
+	execMu         sync.Mutex // one ExecCommand at a time
+	mgmtMu         sync.Mutex
+	mgmt           *management.Client
+
+	store   database.Store
+	process *javaProcess
+	mu      sync.Mutex
+	status  Status
+	stdin   io.WriteCloser
+	stdout  io.ReadCloser
+	stderr  io.ReadCloser
+	proc    *process.Process
+
+	// Supervisor state
+	done          chan struct{} // closed once the running process has been reaped
+	stopRequested bool
+	stopLogged    bool // the server printed "Stopping server", for adopted processes without exit code
+	lastExit      *ExitInfo
+	crashes       []time.Time
+	restarts      int
+	restartTimer  *time.Timer
+
+	// State machine
+	stateCh     chan struct{} // closed and replaced on every status change
+	bootTimer   *time.Timer
+	bootFailure string
+}
+
+// Origin of a console line
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+	StreamSystem = "system" // messages from the backend itself
+)
 
-	store  database.Store
-	cmd    *exec.Cmd
-	mu     sync.Mutex
-	status Status
-	stdin  io.WriteCloser
-	stdout io.ReadCloser
-	proc   *process.Process
+// historyLines is how many console lines a server keeps for new and resuming clients.
+const historyLines = 500
+
+// LogLine is a console line tagged with the stream it came from.
+type LogLine struct {
+	Seq    int64     `json:"seq"` // increases by one for every line of a server, never reused
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Stream string    `json:"stream"`
+	Text   string    `json:"text"`
+	// Record is the Paper line split into fields and colored spans, nil for
+	// the backend's own lines
+	Record *logparse.Record `json:"record,omitempty"`
+}
+
+// recordLine builds the LogLine of a line read from the java process.
+func recordLine(stream, text string) LogLine {
+	rec := logparse.ParseLine(text, time.Now())
+	if stream == StreamStderr {
+		return LogLine{Stream: stream, Level: "ERROR", Text: "[MC/ERR] " + text, Record: &rec}
+	}
+	level := rec.Level
+	if level == "" {
+		// Stack traces and other continuation lines
+		level = "INFO"
+	}
+	return LogLine{Stream: stream, Level: level, Text: "[MC] " + text, Record: &rec}
 }
 
 type Vitals struct {
-	Status      Status   `json:"status"`
-	CPU         float64  `json:"cpu"`
-	RAM         uint64   `json:"ram"`
-	TotalMemory string   `json:"total_memory"`
-	PlayerCount int      `json:"player_count"`
-	PlayerList  []Player `json:"player_list"`
+	Status      Status    `json:"status"`
+	CPU         float64   `json:"cpu"`
+	RAM         uint64    `json:"ram"`
+	TotalMemory string    `json:"total_memory"`
+	PlayerCount int       `json:"player_count"`
+	PlayerList  []Player  `json:"player_list"`
+	PID         int       `json:"pid,omitempty"`
+	LastExit    *ExitInfo `json:"last_exit,omitempty"`
+	Restarts    int       `json:"restarts"`
+	// Reachable is true when the server answered a Server List Ping,
+	// i.e. it accepts connections and not only has a PID
+	Reachable bool        `json:"reachable"`
+	Ping      *slp.Status `json:"ping,omitempty"`
+	// Query is the last full stat of the query port (enable-query=true)
+	Query     *query.Stat `json:"query,omitempty"`
+	PingError string      `json:"ping_error,omitempty"`
+	// Installed is the Paper version and build of the jar, nil when unknown
+	Installed *updater.Installed `json:"installed,omitempty"`
 }
 
 var uuidLogRegex = regexp.MustCompile(`UUID of player (.+) is ([0-9a-fA-F\-]+)`)
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
+// stoppingLine reports whether the server itself announced its shutdown, a
+// player or a plugin printing the same text doesn't count.
+func stoppingLine(rec *logparse.Record) bool {
+	return rec != nil && !rec.Time.IsZero() && rec.Logger == "" && rec.Message == "Stopping server"
+}
+
 func CleanString(input string) string {
 	return strings.TrimSpace(ansiRegex.ReplaceAllString(input, ""))
 }
@@ -95,62 +210,134 @@ func (s *Server) Start() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	// Check if server is already running if not return an error
-	if s.status == StatusRunning {
-		return errors.New("Server is already running")
+	if s.status != StatusStopped && s.status != StatusCrashed {
+		return fmt.Errorf("Server is already %s", strings.ToLower(s.status.String()))
 	}
-	s.cmd = exec.Command("java", "-Xmx"+s.RAM, "-Xms"+s.RAM, "-jar", s.JarFile, "nogui")
-	s.cmd.Dir = s.WorkDir
+	// A manual start overrides any pending automatic restart
+	s.cancelRestart()
+	return s.start()
+}
 
-	pipeIn, errIn := s.cmd.StdinPipe()
-	if errIn != nil {
-		return errIn
+// start spawns the java process and hands it over to the supervisor.
+// The caller must hold s.mu.
+func (s *Server) start() error {
+	line, _, err := s.commandLine()
+	if err != nil {
+		return err
 	}
-	s.stdin = pipeIn
-
-	pipeOut, errOut := s.cmd.StdoutPipe()
-	if errOut != nil {
-		return errOut
+	if err := java.CheckCompatible(s.Java, filepath.Join(s.WorkDir, s.JarFile)); err != nil {
+		return err
 	}
-	s.stdout = pipeOut
+	if err := s.applyPort(); err != nil {
+		return err
+	}
+	if err := s.applyRCON(); err != nil {
+		return err
+	}
+	cmd := exec.Command(line[0], line[1:]...)
+	cmd.Dir = s.WorkDir
 
-	if err := s.cmd.Start(); err != nil {
+	var stdin io.WriteCloser
+	var stdout, stderr *os.File
+	if s.Detach {
+		stdin, stdout, stderr, err = startDetached(cmd, s.WorkDir)
+	} else {
+		stdin, stdout, stderr, err = startAttached(cmd)
+	}
+	if err != nil {
 		return err
 	}
+	p := spawned(cmd)
+
 	// 2. Create process inspector
-	var err error
-	s.proc, err = process.NewProcess(int32(s.cmd.Process.Pid))
+	proc, err := process.NewProcess(int32(p.pid))
 	if err != nil {
-		s.cmd.Process.Kill()
+		p.Kill()
+		<-p.exited
+		stdin.Close()
+		stdout.Close()
+		stderr.Close()
 		return err
 	}
 
-	s.status = StatusRunning
-	go s.StreamLogs()
+	var logs, errs io.ReadCloser = stdout, stderr
+	if s.Detach {
+		logs = &logTail{file: stdout, exited: p.exited, limit: maxConsoleFile}
+		errs = &logTail{file: stderr, exited: p.exited, limit: maxConsoleFile}
+		created, _ := proc.CreateTime()
+		state := runState{PID: p.pid, CreateTime: created, StartedAt: time.Now(), Command: line}
+		if err := writeRunState(s.WorkDir, state); err != nil {
+			// Still runs fine, it just can't be adopted after a backend restart
+			go s.Broadcast("[WARN] Could not write the run state: " + err.Error())
+		}
+	}
+
+	s.process = p
+	s.stdin = stdin
+	s.stdout = logs
+	s.stderr = errs
+	s.proc = proc
+	s.done = make(chan struct{})
+	s.stopRequested = false
+	s.stopLogged = false
+	s.bootFailure = ""
+	s.setStatus(StatusStarting)
+	if s.StartupTimeout > 0 {
+		s.bootTimer = time.AfterFunc(s.StartupTimeout, func() { s.bootTimeout(p) })
+	}
+	go s.supervise(p, logs, errs, s.done)
 	return nil
 }
 
-func (s *Server) Stop() error {
-	if s.GetStatus() == StatusStopped {
-		return errors.New("server already stopped")
+// startAttached starts cmd with its console on pipes owned by the backend.
+func startAttached(cmd *exec.Cmd) (io.WriteCloser, *os.File, *os.File, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	s.SendCommand("stop")
-	if err := s.cmd.Wait(); err != nil {
-		s.mu.Lock()
-		s.proc = nil
-		s.status = StatusStopped
-		s.mu.Unlock()
-		return err
+	// Plain pipes instead of StdoutPipe, so Wait can run while the output is still read
+	stdout, out, err := os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stderr, errOut, err := os.Pipe()
+	if err != nil {
+		stdout.Close()
+		out.Close()
+		return nil, nil, nil, err
+	}
+	cmd.Stdout = out
+	cmd.Stderr = errOut
+	err = cmd.Start()
+	out.Close()
+	errOut.Close()
+	if err != nil {
+		stdout.Close()
+		stderr.Close()
+		return nil, nil, nil, err
 	}
+	return stdin, stdout, stderr, nil
+}
 
-	s.mu.Lock()
-	s.proc = nil
-	s.status = StatusStopped
-	s.mu.Unlock()
+func (s *Server) GetVitals() Vitals {
+	vitals := s.processVitals()
+	if vitals.Status != StatusRunning && vitals.Status != StatusStarting {
+		return vitals
+	}
 
-	return nil
+	// Ask the game port itself, outside the lock
+	status, err := slp.Ping(s.gameAddr(), pingTimeout)
+	if err != nil {
+		vitals.PingError = err.Error()
+		return vitals
+	}
+	vitals.Reachable = true
+	vitals.Ping = status
+	return vitals
 }
 
-func (s *Server) GetVitals() Vitals {
+// processVitals reads the status and the process usage.
+func (s *Server) processVitals() Vitals {
 	// ToDo: if satus failes in front end add Text Marshal
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -165,12 +352,16 @@ func (s *Server) GetVitals() Vitals {
 		TotalMemory: s.RAM,
 		PlayerCount: len(onlineList),
 		PlayerList:  onlineList,
+		LastExit:    s.lastExit,
+		Restarts:    s.restarts,
+		Query:       s.lastQuery,
 	}
 
 	// 1. If Server is not running, returm basic status (0 CPU/RAM)
-	if s.cmd == nil || s.cmd.Process == nil || s.status != StatusRunning {
+	if s.process == nil || s.proc == nil {
 		return vitals
 	}
+	vitals.PID = s.process.pid
 
 	// 3. GET CPU %
 	cpu, err := s.proc.Percent(0)
@@ -189,14 +380,20 @@ func (s *Server) GetVitals() Vitals {
 	return vitals
 }
 
-func (s *Server) StreamLogs() {
-	scanner := bufio.NewScanner(s.stdout)
+func (s *Server) StreamLogs(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
 
 	for scanner.Scan() {
 		text := scanner.Text()
-		s.Broadcast("[MC] " + text)
+		line := recordLine(StreamStdout, text)
+		s.emit(line)
 
 		cleanText := CleanString(text)
+		if stoppingLine(line.Record) {
+			s.mu.Lock()
+			s.stopLogged = true
+			s.mu.Unlock()
+		}
 
 		// Capture UUID
 		if strings.Contains(cleanText, "UUID of player") {
@@ -210,75 +407,57 @@ func (s *Server) StreamLogs() {
 			}
 		}
 
-		// Check for players joining
-		if strings.Contains(text, " joined the game") {
-			go s.handleSessionChange(text, true)
-		}
-
-		// Check for players leaving
-		if strings.Contains(text, " left the game") {
-			go s.handleSessionChange(text, false)
-		}
-
-		// Check for players not on WhiteList trying to connect
-		if strings.Contains(text, "): You are not whitelisted on this server!") {
-			go s.handleRejection(text)
+		// Joins, leaves, chat, deaths, whitelist rejections...
+		if ev, ok := s.parser.Parse(text); ok {
+			s.handleEvent(ev)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading log %v\n", err)
+		log.Printf("Error reading stdout of %s: %v", s.ID, err)
 	}
 }
 
-func (s *Server) handleRejection(logLine string) {
-	// Line format example:
-	//[13:09:40 INFO]: Disconnecting Bob (/ip:port): You are not whitelisted on this server!
-	//
-	// 1. Extract Username
-	// Split by ": "
-	parts := strings.Split(logLine, ": ")
-	if len(parts) < 3 {
-		return
-	}
-	// Take the part after ".../INFO]" -> "Bob lost conection"
-	// Split by " "
-	subParts := strings.Split(parts[1], " ")
-	if len(subParts) < 3 {
-		return
+// StreamErrors forwards stderr (JVM errors, OutOfMemoryError, class version
+// mismatches) to the console.
+func (s *Server) StreamErrors(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		s.emit(recordLine(StreamStderr, scanner.Text()))
 	}
-	username := CleanString(subParts[1])
-
-	// 2. Persist to DB
-	if username != "" {
-		s.Broadcast("[WARN] Detected blocked player. Saving to DB user: " + username)
-		if err := s.store.UpsertRejectedPlayer(username); err != nil {
-			s.Broadcast("[Error] Failed to save rejected player: " + err.Error())
-		}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Error reading stderr of %s: %v", s.ID, err)
 	}
 }
 
-func (s *Server) handleSessionChange(logLine string, joining bool) {
-	parts := strings.Split(logLine, "]: ")
-	if len(parts) < 2 {
-		return
-	}
-	message := parts[1]
-
-	words := strings.Split(message, " ")
-	if len(words) < 4 {
-		return
+// handleEvent reacts to a game event and forwards it to the subscribers.
+func (s *Server) handleEvent(ev logparse.Event) {
+	switch ev.Type {
+	case logparse.TypeDone:
+		// Paper prints `Done (12.345s)! For help, type "help"` once the world is loaded
+		s.markRunning()
+	case logparse.TypeJoin, logparse.TypeLeave:
+		s.mu.Lock()
+		s.updateSession(ev)
+		s.mu.Unlock()
+	case logparse.TypeWhitelistReject:
+		go s.handleRejection(ev.Player)
 	}
+	s.Hub.Publish(Message{Kind: KindGame, Game: &ev})
+}
 
-	username := CleanString(words[0])
-	if username == "" {
-		return
+func (s *Server) handleRejection(username string) {
+	s.Broadcast("[WARN] Detected blocked player. Saving to DB user: " + username)
+	if err := s.store.UpsertRejectedPlayer(username); err != nil {
+		s.Broadcast("[Error] Failed to save rejected player: " + err.Error())
 	}
+}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if joining {
+// updateSession keeps OnlinePlayers in sync with joins and leaves.
+// The caller must hold s.mu.
+func (s *Server) updateSession(ev logparse.Event) {
+	username := ev.Player
+	if ev.Type == logparse.TypeJoin {
 		uuid, exist := s.uuidCache[username]
 		if !exist {
 			uuid = ""
@@ -291,36 +470,115 @@ func (s *Server) handleSessionChange(logLine string, joining bool) {
 	} else {
 		delete(s.OnlinePlayers, username)
 	}
+	s.sessionChanged[username] = time.Now()
 }
 
+// Broadcast sends a message from the backend to the console.
 func (s *Server) Broadcast(msg string) {
-	// Sent msg to frontend
-	select {
-	case s.LogChan <- msg: // Send successfully
-	default: // No browser connected, drop the msg
+	level := "INFO"
+	switch {
+	case strings.HasPrefix(msg, "[ERROR]"), strings.HasPrefix(msg, "[Error]"):
+		level = "ERROR"
+	case strings.HasPrefix(msg, "[WARN]"):
+		level = "WARN"
 	}
+	s.emit(LogLine{Stream: StreamSystem, Level: level, Text: msg})
+}
+
+// PublishJob pushes the state of a job of this server to the subscribers.
+func (s *Server) PublishJob(job jobs.Job) {
+	s.Hub.Publish(Message{Kind: KindJob, Job: &job})
+}
 
-	// Add message to the LogHistory and
+func (s *Server) emit(line LogLine) {
+	// Add message to the LogHistory and sent it to frontend,
+	// under the lock so Subscribe never misses or repeats a line
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.LogHistory = append(s.LogHistory, msg)
+	s.stamp(&line)
+	s.appendHistory(line)
+	s.Hub.Publish(Message{Kind: KindLog, Log: &line})
 
-	// Ring Buffer: keep max 100 lines
-	if len(s.LogHistory) > 100 {
+	// Sent msg to os output
+	fmt.Println(line.Text)
+}
+
+// stamp gives a line the next sequence number, and the current time unless
+// it already has one. The caller must hold s.mu.
+func (s *Server) stamp(line *LogLine) {
+	s.seq++
+	line.Seq = s.seq
+	if line.Time.IsZero() {
+		line.Time = time.Now()
+	}
+}
+
+// appendHistory must be called with s.mu held.
+func (s *Server) appendHistory(line LogLine) {
+	s.LogHistory = append(s.LogHistory, line)
+
+	// Ring Buffer: keep max historyLines lines
+	if len(s.LogHistory) > historyLines {
 		s.LogHistory = s.LogHistory[1:]
 	}
+}
 
-	// Sent msg to os output
-	fmt.Println(msg)
+// Subscribe returns the recent history and a subscription that continues
+// exactly where the history ends. Close the subscription when done.
+func (s *Server) Subscribe(buffer int, policy SlowPolicy) ([]LogLine, *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := make([]LogLine, len(s.LogHistory))
+	copy(history, s.LogHistory)
+	return history, s.Hub.Subscribe(buffer, policy)
 }
 
-func (s *Server) GetHistory() []string {
+// Resume is Subscribe for a client that already saw the lines up to the
+// cursor (a LogLine.Seq): only the newer lines are returned. When lines after
+// the cursor already left the history, gap describes them (Kind is empty
+// otherwise). A cursor of 0, or one this server never reached, replays the
+// whole history.
+func (s *Server) Resume(cursor int64, buffer int, policy SlowPolicy) (gap Message, history []LogLine, sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub = s.Hub.Subscribe(buffer, policy)
+	if cursor <= 0 || cursor > s.seq {
+		history = make([]LogLine, len(s.LogHistory))
+		copy(history, s.LogHistory)
+		return Message{}, history, sub
+	}
+
+	// 1. Skip what the client has
+	start := len(s.LogHistory)
+	for i, line := range s.LogHistory {
+		if line.Seq > cursor {
+			start = i
+			break
+		}
+	}
+	history = make([]LogLine, len(s.LogHistory)-start)
+	copy(history, s.LogHistory[start:])
+
+	// 2. Report what aged out of the buffer
+	next := s.seq + 1
+	if len(history) > 0 {
+		next = history[0].Seq
+	}
+	if next > cursor+1 {
+		gap = gapMessage(cursor, next)
+	}
+	return gap, history, sub
+}
+
+func (s *Server) GetHistory() []LogLine {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Create a copy to be safe
-	history := make([]string, len(s.LogHistory))
+	history := make([]LogLine, len(s.LogHistory))
 	copy(history, s.LogHistory)
 
 	return history
@@ -330,8 +588,8 @@ func (s *Server) SendCommand(cmd string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.status != StatusRunning {
-		return errors.New("server is already stopped")
+	if s.status != StatusRunning && s.status != StatusStarting {
+		return fmt.Errorf("server is %s", strings.ToLower(s.status.String()))
 	}
 
 	if s.stdin == nil {
@@ -342,6 +600,22 @@ func (s *Server) SendCommand(cmd string) error {
 	return err
 }
 
+func (s *Server) PlayerCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.OnlinePlayers)
+}
+
+// PID returns the java process ID, 0 when it is not running.
+func (s *Server) PID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.process == nil || s.proc == nil {
+		return 0
+	}
+	return s.process.pid
+}
+
 func (s *Server) GetStatus() Status {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -350,16 +624,56 @@ func (s *Server) GetStatus() Status {
 
 func NewServer(workDir string, jarFile string, ram string, store database.Store) *Server {
 	return &Server{
-		WorkDir:       workDir,
-		JarFile:       jarFile,
-		RAM:           ram,
-		LogChan:       make(chan string),
-		LogHistory:    make([]string, 0),
-		status:        StatusStopped,
-		store:         store,
-		OnlinePlayers: make(map[string]Player),
-		uuidCache:     make(map[string]string),
-
-		Args: []string{},
+		WorkDir:        workDir,
+		JarFile:        jarFile,
+		RAM:            ram,
+		Hub:            NewHub(),
+		LogHistory:     make([]LogLine, 0),
+		status:         StatusStopped,
+		stateCh:        make(chan struct{}),
+		store:          store,
+		OnlinePlayers:  make(map[string]Player),
+		uuidCache:      make(map[string]string),
+		sessionChanged: make(map[string]time.Time),
+		parser:         logparse.NewParser(),
+		Restart:        DefaultRestartPolicy(),
+
+		StartupTimeout: 5 * time.Minute,
+		StopTimeout:    time.Minute,
+		TermTimeout:    defaultTermTimeout,
+
+		Args:    []string{"nogui"},
+		Profile: BuiltinProfiles[0],
+		Java:    java.Runtime{Path: "java"},
+	}
+}
+
+// CommandLine returns the java invocation Start would run, plus heap warnings.
+func (s *Server) CommandLine() ([]string, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commandLine()
+}
+
+// commandLine must be called with s.mu held.
+func (s *Server) commandLine() ([]string, []string, error) {
+	heap, warnings, err := ValidateHeap(s.RAM)
+	if err != nil {
+		return nil, nil, err
+	}
+	line := BuildCommandLine(s.Java.Path, s.RAM, ProfileFlags(s.Profile, heap, s.Java.Major), s.JarFile, s.Args)
+	return line, warnings, nil
+}
+
+// applyPort keeps server-port in sync with the instance. The caller must hold s.mu.
+func (s *Server) applyPort() error {
+	if s.Port <= 0 {
+		return nil
+	}
+	port := strconv.Itoa(s.Port)
+	props, err := config.LoadProperties(s.WorkDir)
+	if err == nil && props["server-port"] == port {
+		return nil
 	}
+	return config.SaveProperties(s.WorkDir, map[string]string{"server-port": port})
 }
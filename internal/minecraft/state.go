@@ -0,0 +1,88 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// setStatus moves the state machine and wakes up everyone in WaitReady.
+// The caller must hold s.mu.
+func (s *Server) setStatus(status Status) {
+	if s.status == status {
+		return
+	}
+	s.status = status
+	close(s.stateCh)
+	s.stateCh = make(chan struct{})
+	s.publish(Event{Type: "state", Status: status})
+}
+
+// markRunning is called by StreamLogs when the "Done" line shows up.
+func (s *Server) markRunning() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status != StatusStarting {
+		return
+	}
+	s.stopBootTimer()
+	s.setStatus(StatusRunning)
+}
+
+// bootTimeout fails a boot that never reached "Done". The process is killed,
+// the supervisor then reports the exit as a crash.
+func (s *Server) bootTimeout(p *javaProcess) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.process != p || s.status != StatusStarting {
+		return
+	}
+	s.bootFailure = fmt.Sprintf("startup timed out after %s", s.StartupTimeout)
+	go s.Broadcast("[ERROR] Server did not finish booting: " + s.bootFailure)
+	p.Kill()
+}
+
+// stopBootTimer must be called with s.mu held.
+func (s *Server) stopBootTimer() {
+	if s.bootTimer != nil {
+		s.bootTimer.Stop()
+		s.bootTimer = nil
+	}
+}
+
+// WaitReady blocks until the server is Running. It returns the failure
+// reason if the boot ends in Stopped or Crashed instead.
+func (s *Server) WaitReady(ctx context.Context) error {
+	for {
+		s.mu.Lock()
+		status := s.status
+		changed := s.stateCh
+		exit := s.lastExit
+		s.mu.Unlock()
+
+		switch status {
+		case StatusRunning:
+			return nil
+		case StatusStopped, StatusCrashed:
+			if exit != nil {
+				return errors.New("server failed to start: " + exit.Reason)
+			}
+			return errors.New("server is not running")
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Active reports whether a java process is up: starting, running or stopping.
+func (s *Server) Active() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status != StatusStopped && s.status != StatusCrashed
+}
@@ -0,0 +1,105 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// consoleLoop answers like a booted server: it exits on "stop".
+const consoleLoop = `while read cmd; do [ "$cmd" = stop ] && exit 0; done`
+
+func TestWaitReadyBoot(t *testing.T) {
+	// "Done" only once the test says so, to see the server in Starting first
+	s := fakeJava(t, "read go\n"+doneLine+"\n"+consoleLoop)
+	if err := s.Start(); err != nil {
+		t.Fatalf("[TEST] Start failed: %v", err)
+	}
+	defer s.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("[TEST] WaitReady before Done = %v, want: %v", err, context.DeadlineExceeded)
+	}
+	if status := s.GetStatus(); status != StatusStarting {
+		t.Fatalf("[TEST] status before Done = %s, want: %s", status, StatusStarting)
+	}
+
+	if err := s.SendCommand("go"); err != nil {
+		t.Fatalf("[TEST] SendCommand failed: %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatalf("[TEST] WaitReady after Done = %v, want: nil", err)
+	}
+	if status := s.GetStatus(); status != StatusRunning {
+		t.Errorf("[TEST] status after Done = %s, want: %s", status, StatusRunning)
+	}
+}
+
+func TestWaitReadyFailure(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string // "" never starts the server
+		startupTimeout time.Duration
+		wantErr        string
+		wantStatus     Status
+	}{
+		{"Never started", "", 0, "server is not running", StatusStopped},
+		{"Exits before Done", "echo booting\nexit 3", 0, "exited with code 3", StatusCrashed},
+		{"Boot timeout", "exec sleep 30", 200 * time.Millisecond, "startup timed out after 200ms", StatusCrashed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := fakeJava(t, tt.body)
+			s.Restart.Enabled = false
+			s.StartupTimeout = tt.startupTimeout
+			if tt.body != "" {
+				if err := s.Start(); err != nil {
+					t.Fatalf("[TEST] Start failed: %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err := s.WaitReady(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("[TEST] WaitReady = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if status := s.GetStatus(); status != tt.wantStatus {
+				t.Errorf("[TEST] status = %s, want: %s", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestStoppingLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{"Console format", "[12:00:00 INFO]: Stopping server", true},
+		{"Log file format", "[12:00:00] [Server thread/INFO]: Stopping server", true},
+		{"Colored", "\x1b[0m[12:00:00 INFO]: Stopping server\x1b[m", true},
+		{"Chat", "[12:00:00 INFO]: <Steve> ]: Stopping server", false},
+		{"Plugin", "[12:00:00 INFO]: [Essentials] Stopping server", false},
+		{"No header", "Stopping server", false},
+		{"Other text", "[12:00:00 INFO]: Stopping the server soon", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(t.TempDir(), "server.jar", "1G", nil)
+			s.StreamLogs(strings.NewReader(tt.line + "\n"))
+			if s.stopLogged != tt.want {
+				t.Errorf("[TEST] stopLogged after %q = %v, want: %v", tt.line, s.stopLogged, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// StopStage tells which step of the stop sequence ended the process.
+type StopStage string
+
+const (
+	StopStageCommand StopStage = "stop"    // the server handled "stop" by itself
+	StopStageTerm    StopStage = "SIGTERM" // the JVM shutdown hooks ran after SIGTERM
+	StopStageKill    StopStage = "SIGKILL" // the process had to be killed
+)
+
+// defaultTermTimeout is used when TermTimeout is not set.
+const defaultTermTimeout = 10 * time.Second
+
+// Stop runs the stop sequence with the server's default StopTimeout. Like
+// StopWithTimeout it fails when the server exited non-zero on "stop".
+func (s *Server) Stop() error {
+	_, err := s.StopWithTimeout(s.StopTimeout)
+	return err
+}
+
+// StopWithTimeout sends "save-all" and "stop", then escalates to SIGTERM once
+// the grace period is over and to SIGKILL after TermTimeout if the JVM still
+// hangs around. A zero grace period waits for the "stop" command forever.
+// The error reports a non-zero exit after "stop", the signal stages are
+// reported by the stage only.
+func (s *Server) StopWithTimeout(grace time.Duration) (StopStage, error) {
+	s.mu.Lock()
+	if s.status == StatusStopped || s.status == StatusCrashed {
+		// Stopping also means "don't come back on your own"
+		s.cancelRestart()
+		s.mu.Unlock()
+		return "", errors.New("server already stopped")
+	}
+	done := s.done
+	p := s.process
+	stdin := s.stdin
+	first := s.status != StatusStopping
+	if first {
+		s.stopRequested = true
+		s.setStatus(StatusStopping)
+	}
+	s.mu.Unlock()
+
+	// 1. Ask nicely, outside the lock: a full pipe must not block the
+	// other Server methods. Without a console go straight to the signals.
+	asked := !first
+	if first && stdin != nil {
+		_, err := fmt.Fprintln(stdin, "save-all")
+		if err == nil {
+			_, err = fmt.Fprintln(stdin, "stop")
+		}
+		asked = err == nil
+	}
+	if asked && waitDone(done, grace) {
+		return StopStageCommand, s.stopExitError()
+	}
+
+	// 2. SIGTERM, the JVM still runs its shutdown hooks and saves the worlds
+	if asked {
+		s.Broadcast(fmt.Sprintf("[WARN] Server did not stop within %s, sending SIGTERM", grace))
+	} else {
+		s.Broadcast("[WARN] Console unavailable, sending SIGTERM")
+	}
+	term := s.TermTimeout
+	if term <= 0 {
+		term = defaultTermTimeout
+	}
+	if err := p.Signal(syscall.SIGTERM); err == nil && waitDone(done, term) {
+		return StopStageTerm, nil
+	}
+
+	// 3. SIGKILL
+	s.Broadcast("[WARN] Server ignored SIGTERM, killing the process")
+	if err := p.Kill(); err != nil {
+		return StopStageKill, err
+	}
+	<-done
+	return StopStageKill, nil
+}
+
+// stopExitError turns a non-zero exit after "stop" into an error. An adopted
+// process has no exit code, its exit after "stop" is clean.
+func (s *Server) stopExitError() error {
+	s.mu.Lock()
+	exit := s.lastExit
+	s.mu.Unlock()
+	if exit != nil && exit.Code != 0 && !exit.adopted {
+		return errors.New(exit.Reason)
+	}
+	return nil
+}
+
+// waitDone reports whether done was closed within timeout.
+func waitDone(done chan struct{}, timeout time.Duration) bool {
+	if timeout <= 0 {
+		<-done
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
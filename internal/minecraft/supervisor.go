@@ -0,0 +1,217 @@
+package minecraft
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// ExitInfo describes how the last java process ended.
+type ExitInfo struct {
+	Code     int       `json:"code"`
+	Reason   string    `json:"reason"`
+	Time     time.Time `json:"time"`
+	Expected bool      `json:"expected"` // true when the exit was requested (Stop or "stop" typed in the console)
+	// adopted is set when the process was adopted, Code is then -1 because
+	// nobody can tell the real one
+	adopted bool
+}
+
+// RestartPolicy controls what the supervisor does after a crash.
+type RestartPolicy struct {
+	Enabled    bool
+	MaxRetries int           // crashes tolerated inside Window before giving up
+	Window     time.Duration // sliding window used to detect crash loops
+	BaseDelay  time.Duration // first backoff, doubled for every crash in the window
+	MaxDelay   time.Duration
+}
+
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		Enabled:    true,
+		MaxRetries: 5,
+		Window:     10 * time.Minute,
+		BaseDelay:  5 * time.Second,
+		MaxDelay:   5 * time.Minute,
+	}
+}
+
+// Event is a lifecycle notification pushed to websocket clients.
+type Event struct {
+	Type   string    `json:"type"` // "state" or "exit"
+	Status Status    `json:"status"`
+	Exit   *ExitInfo `json:"exit,omitempty"`
+}
+
+// supervise streams the process output until EOF and then waits for the
+// process to be gone.
+func (s *Server) supervise(p *javaProcess, stdout, stderr io.ReadCloser, done chan struct{}) {
+	go s.watchQuery(done)
+
+	errorsDone := make(chan struct{})
+	go func() {
+		s.StreamErrors(stderr)
+		close(errorsDone)
+	}()
+	s.StreamLogs(stdout)
+	<-errorsDone
+	<-p.exited
+	stdout.Close()
+	stderr.Close()
+
+	s.mu.Lock()
+	exit := newExitInfo(p.err)
+	exit.Expected = s.stopRequested || exit.Code == 0
+	if p.adopted && s.stopLogged {
+		// No exit code, but "stop" was typed in the console
+		exit.Expected = true
+	}
+	if s.bootFailure != "" {
+		exit.Expected = false
+		exit.Reason = s.bootFailure + " (" + exit.Reason + ")"
+	}
+	s.lastExit = &exit
+	s.proc = nil
+	if s.stdin != nil {
+		s.stdin.Close()
+	}
+	s.stdin = nil
+	// Nobody is online on a stopped server
+	s.OnlinePlayers = make(map[string]Player)
+	s.sessionChanged = make(map[string]time.Time)
+	s.lastQuery = nil
+	if s.Detach || p.adopted {
+		removeRunState(s.WorkDir)
+	}
+	s.stopBootTimer()
+	if exit.Expected {
+		s.setStatus(StatusStopped)
+	} else {
+		s.setStatus(StatusCrashed)
+	}
+	close(done)
+
+	delay, restart := s.scheduleRestart(exit)
+	s.mu.Unlock()
+
+	s.Broadcast("[System] Server process " + exit.Reason)
+	s.publish(Event{Type: "exit", Status: s.GetStatus(), Exit: &exit})
+
+	if restart {
+		s.Broadcast(fmt.Sprintf("[System] Restarting in %s...", delay))
+	}
+
+	// Last and outside s.mu, a dial in progress holds their lock for up to 10s
+	s.closeRCON(nil)
+	s.closeManagement(nil)
+}
+
+// scheduleRestart records a crash and arms the restart timer if the policy allows it.
+// The caller must hold s.mu.
+func (s *Server) scheduleRestart(exit ExitInfo) (time.Duration, bool) {
+	if exit.Expected || !s.Restart.Enabled {
+		return 0, false
+	}
+
+	// 1. Keep only the crashes inside the window
+	now := exit.Time
+	recent := s.crashes[:0]
+	for _, t := range s.crashes {
+		if now.Sub(t) <= s.Restart.Window {
+			recent = append(recent, t)
+		}
+	}
+	s.crashes = append(recent, now)
+
+	// 2. Crash loop, give up until someone starts it by hand
+	if len(s.crashes) > s.Restart.MaxRetries {
+		go s.Broadcast(fmt.Sprintf("[ERROR] Crash loop detected (%d crashes in %s). Auto-restart disabled.",
+			len(s.crashes), s.Restart.Window))
+		s.crashes = nil
+		return 0, false
+	}
+
+	// 3. Exponential backoff
+	delay := s.Restart.BaseDelay << (len(s.crashes) - 1)
+	if delay <= 0 || delay > s.Restart.MaxDelay {
+		delay = s.Restart.MaxDelay
+	}
+
+	s.restartTimer = time.AfterFunc(delay, s.autoRestart)
+	return delay, true
+}
+
+func (s *Server) autoRestart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.restartTimer == nil {
+		// Cancelled while the timer was firing
+		return
+	}
+	s.restartTimer = nil
+	if s.status != StatusCrashed {
+		return
+	}
+	if err := s.start(); err != nil {
+		go s.Broadcast("[ERROR] Auto-restart failed: " + err.Error())
+		return
+	}
+	s.restarts++
+}
+
+// cancelRestart drops a pending automatic restart. The caller must hold s.mu.
+func (s *Server) cancelRestart() {
+	if s.restartTimer != nil {
+		s.restartTimer.Stop()
+		s.restartTimer = nil
+	}
+}
+
+// retire readies a stopped server for its removal: no pending automatic
+// restart may bring it back. It fails when the server runs.
+func (s *Server) retire() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != StatusStopped && s.status != StatusCrashed {
+		return errors.New("stop the server before deleting it")
+	}
+	s.cancelRestart()
+	return nil
+}
+
+func (s *Server) publish(ev Event) {
+	s.Hub.Publish(Message{Kind: KindLifecycle, Event: &ev})
+}
+
+func newExitInfo(err error) ExitInfo {
+	exit := ExitInfo{Time: time.Now(), Reason: "exited normally"}
+	if err == nil {
+		return exit
+	}
+
+	if errors.Is(err, errAdoptedExit) {
+		exit.Code = -1
+		exit.adopted = true
+		exit.Reason = "exited, " + err.Error()
+		return exit
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		exit.Code = -1
+		exit.Reason = "lost: " + err.Error()
+		return exit
+	}
+
+	exit.Code = exitErr.ExitCode()
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		exit.Reason = "killed by signal " + ws.Signal().String()
+		return exit
+	}
+	exit.Reason = fmt.Sprintf("exited with code %d", exit.Code)
+	return exit
+}
@@ -0,0 +1,161 @@
+package minecraft
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"paperMC_backend/internal/java"
+)
+
+// fakeJava returns a server whose "java" is a shell script running body.
+func fakeJava(t *testing.T, body string) *Server {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("[TEST] needs /bin/sh")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "java")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("[TEST] Failed to write fake java: %v", err)
+	}
+	s := NewServer(dir, "server.jar", "1G", nil)
+	s.Java = java.Runtime{Path: path}
+	return s
+}
+
+// doneLine is what Paper prints once the world is loaded.
+const doneLine = `echo '[12:00:00 INFO]: Done (1.234s)! For help, type "help"'`
+
+// lifecycle collects the statuses published until the n-th exit event.
+func lifecycle(t *testing.T, sub *Subscription, exits int) []Status {
+	t.Helper()
+	var statuses []Status
+	timeout := time.After(10 * time.Second)
+	for exits > 0 {
+		select {
+		case msg := <-sub.C():
+			if msg.Kind != KindLifecycle {
+				continue
+			}
+			if msg.Event.Type == "exit" {
+				exits--
+				continue
+			}
+			statuses = append(statuses, msg.Event.Status)
+		case <-timeout:
+			t.Fatalf("[TEST] Timed out, statuses so far: %v", statuses)
+		}
+	}
+	return statuses
+}
+
+func TestScheduleRestart(t *testing.T) {
+	policy := RestartPolicy{
+		Enabled:    true,
+		MaxRetries: 3,
+		Window:     10 * time.Minute,
+		BaseDelay:  time.Second,
+		MaxDelay:   5 * time.Second,
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	tests := []struct {
+		name        string
+		policy      RestartPolicy
+		crashes     []time.Time
+		expected    bool
+		wantRestart bool
+		wantDelay   time.Duration
+		wantCrashes int
+	}{
+		{"Expected exit", policy, nil, true, false, 0, 0},
+		{"Disabled", RestartPolicy{}, nil, false, false, 0, 0},
+		{"First crash", policy, nil, false, true, time.Second, 1},
+		{"Backoff doubles", policy, []time.Time{ago(time.Minute)}, false, true, 2 * time.Second, 2},
+		{"Backoff doubles again", policy, []time.Time{ago(2 * time.Minute), ago(time.Minute)}, false, true, 4 * time.Second, 3},
+		{"Clamped to MaxDelay", RestartPolicy{Enabled: true, MaxRetries: 10, Window: time.Hour, BaseDelay: time.Second, MaxDelay: 5 * time.Second},
+			[]time.Time{ago(3 * time.Minute), ago(2 * time.Minute), ago(time.Minute)}, false, true, 5 * time.Second, 4},
+		{"Old crashes pruned", policy, []time.Time{ago(30 * time.Minute), ago(20 * time.Minute), ago(time.Minute)}, false, true, 2 * time.Second, 2},
+		{"Crash loop", policy, []time.Time{ago(3 * time.Minute), ago(2 * time.Minute), ago(time.Minute)}, false, false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{Hub: NewHub(), Restart: tt.policy, crashes: slices.Clone(tt.crashes)}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			defer s.cancelRestart()
+
+			delay, restart := s.scheduleRestart(ExitInfo{Code: 1, Time: now, Expected: tt.expected})
+			if restart != tt.wantRestart || delay != tt.wantDelay {
+				t.Errorf("[TEST] scheduleRestart = %s, %v, want: %s, %v", delay, restart, tt.wantDelay, tt.wantRestart)
+			}
+			if len(s.crashes) != tt.wantCrashes {
+				t.Errorf("[TEST] crashes = %d, want: %d", len(s.crashes), tt.wantCrashes)
+			}
+			if armed := s.restartTimer != nil; armed != tt.wantRestart {
+				t.Errorf("[TEST] restart timer armed = %v, want: %v", armed, tt.wantRestart)
+			}
+		})
+	}
+}
+
+func TestSupervisorCrashRestart(t *testing.T) {
+	s := fakeJava(t, doneLine+"\nexit 1")
+	// One automatic restart, the second crash is a crash loop
+	s.Restart = RestartPolicy{Enabled: true, MaxRetries: 1, Window: time.Minute, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	sub := s.Hub.Subscribe(64, DropWithGap)
+	defer sub.Close()
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("[TEST] Start failed: %v", err)
+	}
+	got := lifecycle(t, sub, 2)
+
+	want := []Status{StatusStarting, StatusRunning, StatusCrashed, StatusStarting, StatusRunning, StatusCrashed}
+	if !slices.Equal(got, want) {
+		t.Errorf("[TEST] statuses = %v, want: %v", got, want)
+	}
+	vitals := s.processVitals()
+	if vitals.Restarts != 1 {
+		t.Errorf("[TEST] restarts = %d, want: 1", vitals.Restarts)
+	}
+	if vitals.LastExit == nil || vitals.LastExit.Code != 1 || vitals.LastExit.Expected {
+		t.Errorf("[TEST] last exit = %+v, want: code 1, unexpected", vitals.LastExit)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.restartTimer != nil {
+		t.Errorf("[TEST] a restart is still pending after the crash loop")
+	}
+}
+
+func TestSupervisorExitDuringDial(t *testing.T) {
+	s := fakeJava(t, "exit 0")
+	// An RCON dial in progress holds rconMu
+	s.rconMu.Lock()
+	defer s.rconMu.Unlock()
+
+	sub := s.Hub.Subscribe(64, DropWithGap)
+	defer sub.Close()
+	if err := s.Start(); err != nil {
+		t.Fatalf("[TEST] Start failed: %v", err)
+	}
+	lifecycle(t, sub, 1)
+
+	status := make(chan Status)
+	go func() { status <- s.GetStatus() }()
+	select {
+	case got := <-status:
+		if got != StatusStopped {
+			t.Errorf("[TEST] status = %s, want: %s", got, StatusStopped)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("[TEST] GetStatus blocked behind the RCON lock")
+	}
+}
@@ -0,0 +1,168 @@
+// Package query implements the GameSpy4 Query protocol Minecraft serves over
+// UDP when enable-query=true. Unlike the Server List Ping, the full stat lists
+// every online player, the plugins and the map.
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/logparse"
+)
+
+// Packet types
+const (
+	typeStat      = 0x00
+	typeHandshake = 0x09
+)
+
+var (
+	magic        = []byte{0xFE, 0xFD}
+	errBadPacket = errors.New("query: malformed packet")
+
+	// Constant paddings of the full stat response
+	statPadding    = []byte("splitnum\x00\x80\x00")
+	playersPadding = []byte("\x01player_\x00\x00")
+)
+
+// Stat is the full stat of a server.
+type Stat struct {
+	MOTD       string   `json:"motd"`
+	GameType   string   `json:"game_type"` // "SMP"
+	GameID     string   `json:"game_id"`   // "MINECRAFT"
+	Version    string   `json:"version"`
+	Software   string   `json:"software"` // "Paper on 1.21.4-R0.1-SNAPSHOT", from the plugins field
+	Plugins    []string `json:"plugins"`
+	Map        string   `json:"map"`
+	NumPlayers int      `json:"num_players"`
+	MaxPlayers int      `json:"max_players"`
+	HostPort   int      `json:"host_port"`
+	HostIP     string   `json:"host_ip"`
+	Players    []string `json:"players"`
+}
+
+// FullStat asks the query port at addr ("host:port") for the full stat.
+// timeout covers the whole exchange.
+func FullStat(addr string, timeout time.Duration) (*Stat, error) {
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// Only the low 4 bits of each byte of the session ID are used
+	session := int32(time.Now().UnixNano()) & 0x0F0F0F0F
+
+	// 1. Handshake: the challenge token comes back as a decimal string
+	resp, err := exchange(conn, request(typeHandshake, session, nil))
+	if err != nil {
+		return nil, err
+	}
+	token, err := strconv.ParseInt(string(bytes.TrimRight(resp, "\x00")), 10, 32)
+	if err != nil {
+		return nil, errBadPacket
+	}
+
+	// 2. Full stat: the token followed by four bytes of padding
+	payload := binary.BigEndian.AppendUint32(nil, uint32(int32(token)))
+	payload = append(payload, 0, 0, 0, 0)
+	resp, err = exchange(conn, request(typeStat, session, payload))
+	if err != nil {
+		return nil, err
+	}
+	return parseFullStat(resp)
+}
+
+func request(kind byte, session int32, payload []byte) []byte {
+	packet := append([]byte{}, magic...)
+	packet = append(packet, kind)
+	packet = binary.BigEndian.AppendUint32(packet, uint32(session))
+	return append(packet, payload...)
+}
+
+// exchange sends a request and returns the response after its type and session ID.
+func exchange(conn net.Conn, packet []byte) ([]byte, error) {
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	if n < 5 || buf[0] != packet[2] || !bytes.Equal(buf[1:5], packet[3:7]) {
+		return nil, errBadPacket
+	}
+	return buf[5:n], nil
+}
+
+// parseFullStat reads the key/value section and the player list.
+func parseFullStat(data []byte) (*Stat, error) {
+	if !bytes.HasPrefix(data, statPadding) {
+		return nil, errBadPacket
+	}
+	data = data[len(statPadding):]
+
+	// 1. NUL separated key/value pairs, an empty key ends them
+	values := make(map[string]string)
+	for {
+		key, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok {
+			return nil, errBadPacket
+		}
+		data = rest
+		if len(key) == 0 {
+			break
+		}
+		value, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok {
+			return nil, errBadPacket
+		}
+		data = rest
+		values[string(key)] = string(value)
+	}
+
+	// 2. Player names, an empty one ends them
+	players := []string{}
+	if bytes.HasPrefix(data, playersPadding) {
+		data = data[len(playersPadding):]
+		for {
+			name, rest, ok := bytes.Cut(data, []byte{0})
+			if !ok || len(name) == 0 {
+				break
+			}
+			players = append(players, string(name))
+			data = rest
+		}
+	}
+
+	stat := &Stat{
+		MOTD:     logparse.Plain(values["hostname"]),
+		GameType: values["gametype"],
+		GameID:   values["game_id"],
+		Version:  values["version"],
+		Map:      values["map"],
+		HostIP:   values["hostip"],
+		Plugins:  []string{},
+		Players:  players,
+	}
+	stat.NumPlayers, _ = strconv.Atoi(values["numplayers"])
+	stat.MaxPlayers, _ = strconv.Atoi(values["maxplayers"])
+	stat.HostPort, _ = strconv.Atoi(values["hostport"])
+
+	// "Paper on 1.21.4-R0.1-SNAPSHOT: LuckPerms 5.4.102; ViaVersion 5.2.1"
+	software, plugins, _ := strings.Cut(values["plugins"], ": ")
+	stat.Software = software
+	for _, plugin := range strings.Split(plugins, "; ") {
+		if plugin != "" {
+			stat.Plugins = append(stat.Plugins, plugin)
+		}
+	}
+	return stat, nil
+}
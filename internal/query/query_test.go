@@ -0,0 +1,90 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const challenge = 9513307
+
+// fakeServer answers a handshake and a full stat like the vanilla query listener.
+func fakeServer(t *testing.T, players []string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:n]
+			if n < 7 || !bytes.Equal(req[:2], magic) {
+				continue
+			}
+			header := append([]byte{req[2]}, req[3:7]...)
+			switch req[2] {
+			case typeHandshake:
+				conn.WriteTo(append(header, []byte("9513307\x00")...), addr)
+			case typeStat:
+				if n != 15 || binary.BigEndian.Uint32(req[7:11]) != challenge {
+					continue // a basic stat or a wrong token gets no answer
+				}
+				var resp bytes.Buffer
+				resp.Write(header)
+				resp.Write(statPadding)
+				for _, kv := range [][2]string{
+					{"hostname", "§aA Paper server"}, {"gametype", "SMP"}, {"game_id", "MINECRAFT"},
+					{"version", "1.21.4"}, {"plugins", "Paper on 1.21.4-R0.1-SNAPSHOT: LuckPerms 5.4.102; ViaVersion 5.2.1"},
+					{"map", "world"}, {"numplayers", "2"}, {"maxplayers", "20"}, {"hostport", "25565"}, {"hostip", "0.0.0.0"},
+				} {
+					resp.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+				}
+				resp.WriteByte(0)
+				resp.Write(playersPadding)
+				for _, name := range players {
+					resp.WriteString(name + "\x00")
+				}
+				resp.WriteByte(0)
+				conn.WriteTo(resp.Bytes(), addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestFullStat(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []string
+	}{
+		{"Players online", []string{"Steve", ".BedrockAlex"}},
+		{"Empty server", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stat, err := FullStat(fakeServer(t, tt.players), time.Second)
+			if err != nil {
+				t.Fatalf("[TEST] FullStat failed: %v", err)
+			}
+			if strings.Join(stat.Players, ",") != strings.Join(tt.players, ",") {
+				t.Errorf("[TEST] Players = %v, want: %v", stat.Players, tt.players)
+			}
+			if stat.MOTD != "A Paper server" || stat.Map != "world" || stat.MaxPlayers != 20 || stat.HostPort != 25565 {
+				t.Errorf("[TEST] unexpected stat %+v", stat)
+			}
+			if stat.Software != "Paper on 1.21.4-R0.1-SNAPSHOT" || len(stat.Plugins) != 2 || stat.Plugins[0] != "LuckPerms 5.4.102" {
+				t.Errorf("[TEST] Software = %q, Plugins = %v", stat.Software, stat.Plugins)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package rcon is a client for the Source RCON protocol Minecraft speaks on
+// rcon.port: unlike the console, every command gets its response back.
+package rcon
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Packet types
+const (
+	TypeResponse = 0 // SERVERDATA_RESPONSE_VALUE
+	TypeCommand  = 2 // SERVERDATA_EXECCOMMAND, also the type of the auth response
+	TypeAuth     = 3 // SERVERDATA_AUTH
+)
+
+// MaxCommand is the longest command Minecraft accepts (1446 bytes of payload).
+const MaxCommand = 1446
+
+var (
+	ErrAuth     = errors.New("rcon: wrong password")
+	ErrTooLong  = fmt.Errorf("rcon: command longer than %d bytes", MaxCommand)
+	errBadFrame = errors.New("rcon: malformed packet")
+)
+
+// Packet is one frame: int32 length, int32 id, int32 type, body, two NUL bytes.
+type Packet struct {
+	ID   int32
+	Type int32
+	Body string
+}
+
+// WritePacket encodes p, little endian like the rest of the protocol.
+func WritePacket(w io.Writer, p Packet) error {
+	buf := make([]byte, 4+4+4+len(p.Body)+2)
+	binary.LittleEndian.PutUint32(buf[0:], uint32(len(buf)-4))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(p.ID))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(p.Type))
+	copy(buf[12:], p.Body)
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadPacket decodes one packet.
+func ReadPacket(r io.Reader) (Packet, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return Packet{}, err
+	}
+	if size < 10 || size > 64*1024 {
+		return Packet{}, errBadFrame
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Packet{}, err
+	}
+	return Packet{
+		ID:   int32(binary.LittleEndian.Uint32(buf[0:])),
+		Type: int32(binary.LittleEndian.Uint32(buf[4:])),
+		Body: strings.TrimRight(string(buf[8:]), "\x00"),
+	}, nil
+}
+
+// Client is an authenticated RCON connection. Commands are serialized.
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	nextID  int32
+	timeout time.Duration
+}
+
+// Dial connects and authenticates. timeout applies to the connection and to
+// every command afterwards.
+func Dial(addr, password string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}
+
+	// 1. Authenticate, a failed login answers with id -1
+	id := c.id()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := WritePacket(conn, Packet{ID: id, Type: TypeAuth, Body: password}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for {
+		p, err := ReadPacket(c.reader)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		// Some servers send an empty response value before the auth response
+		if p.Type != TypeCommand {
+			continue
+		}
+		if p.ID == -1 {
+			conn.Close()
+			return nil, ErrAuth
+		}
+		if p.ID != id {
+			conn.Close()
+			return nil, errBadFrame
+		}
+		return c, nil
+	}
+}
+
+func (c *Client) id() int32 {
+	c.nextID++
+	return c.nextID
+}
+
+// Command runs cmd and returns what the server answered.
+func (c *Client) Command(cmd string) (string, error) {
+	if len(cmd) > MaxCommand {
+		return "", ErrTooLong
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 1. Send the command followed by an invalid packet: responses longer
+	// than 4096 bytes are split, the server answers the second packet only
+	// after the last part of the first
+	id, end := c.id(), c.id()
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := WritePacket(c.conn, Packet{ID: id, Type: TypeCommand, Body: cmd}); err != nil {
+		return "", err
+	}
+	if err := WritePacket(c.conn, Packet{ID: end, Type: TypeResponse}); err != nil {
+		return "", err
+	}
+
+	// 2. Collect the parts until the answer to the marker
+	var out strings.Builder
+	for {
+		p, err := ReadPacket(c.reader)
+		if err != nil {
+			return "", err
+		}
+		switch p.ID {
+		case id:
+			out.WriteString(p.Body)
+		case end:
+			return out.String(), nil
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
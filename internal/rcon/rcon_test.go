@@ -0,0 +1,112 @@
+package rcon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer answers like the vanilla RconClient: responses are split into
+// 4096 byte packets and unknown packet types get "Unknown request".
+func fakeServer(t *testing.T, password string, handle func(cmd string) string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				authed := false
+				for {
+					p, err := ReadPacket(conn)
+					if err != nil {
+						return
+					}
+					switch {
+					case p.Type == TypeAuth:
+						authed = p.Body == password
+						id := p.ID
+						if !authed {
+							id = -1
+						}
+						WritePacket(conn, Packet{ID: id, Type: TypeCommand})
+					case p.Type == TypeCommand && authed:
+						out := handle(p.Body)
+						for len(out) > 4096 {
+							WritePacket(conn, Packet{ID: p.ID, Type: TypeResponse, Body: out[:4096]})
+							out = out[4096:]
+						}
+						WritePacket(conn, Packet{ID: p.ID, Type: TypeResponse, Body: out})
+					default:
+						WritePacket(conn, Packet{ID: p.ID, Type: TypeResponse, Body: fmt.Sprintf("Unknown request %x", p.Type)})
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCommand(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	addr := fakeServer(t, "secret", func(cmd string) string {
+		switch cmd {
+		case "list":
+			return "There are 0 of a max of 20 players online: "
+		case "long":
+			return long
+		default:
+			return "Unknown or incomplete command"
+		}
+	})
+
+	client, err := Dial(addr, "secret", time.Second)
+	if err != nil {
+		t.Fatalf("[TEST] Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		name string
+		cmd  string
+		want string
+	}{
+		{"Simple", "list", "There are 0 of a max of 20 players online: "},
+		{"Split response", "long", long},
+		{"Unknown command", "nope", "Unknown or incomplete command"},
+		{"Again after a split", "list", "There are 0 of a max of 20 players online: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.Command(tt.cmd)
+			if err != nil {
+				t.Fatalf("[TEST] Command(%q) failed: %v", tt.cmd, err)
+			}
+			if got != tt.want {
+				t.Errorf("[TEST] Command(%q) = %d bytes, want: %d bytes", tt.cmd, len(got), len(tt.want))
+			}
+		})
+	}
+
+	if _, err := client.Command(strings.Repeat("a", MaxCommand+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("[TEST] long command error = %v, want: ErrTooLong", err)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	addr := fakeServer(t, "secret", func(string) string { return "" })
+	if _, err := Dial(addr, "guess", time.Second); !errors.Is(err, ErrAuth) {
+		t.Errorf("[TEST] Dial error = %v, want: ErrAuth", err)
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday 2025-01-15 10:30
+	from := time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"Nightly", "0 4 * * *", time.Date(2025, time.January, 16, 4, 0, 0, 0, time.UTC)},
+		{"Every 15 minutes", "*/15 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{"Later today", "0 12,18 * * *", time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC)},
+		{"Sunday as 7", "0 3 * * 7", time.Date(2025, time.January, 19, 3, 0, 0, 0, time.UTC)},
+		{"Weekdays range", "30 6 * * 1-5", time.Date(2025, time.January, 16, 6, 30, 0, 0, time.UTC)},
+		{"Day of month", "0 0 1 * *", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"Leap day", "0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"Shortcut", "@daily", time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"Strictly after", "30 10 * * *", time.Date(2025, time.January, 16, 10, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("[TEST] ParseCron(%q) error = %v", tt.expr, err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("[TEST] Next() = %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("[TEST] ParseCron(%q) expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package scheduler runs cron based restarts of the Minecraft server.
+//
+// Every enabled schedule gets its own goroutine that sleeps until the next
+// occurrence, warns the players with a countdown (10m, 5m, 1m, 10s) and then
+// performs a stop + start. Schedules live in the database; call Reload after
+// changing them, it only restarts the schedules that changed.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/minecraft"
+)
+
+// Warnings are announced this long before the restart.
+var Warnings = []time.Duration{10 * time.Minute, 5 * time.Minute, time.Minute, 10 * time.Second}
+
+type Scheduler struct {
+	servers *minecraft.ServerManager
+	store   database.Store
+
+	mu   sync.Mutex
+	jobs map[int]job // by schedule ID
+}
+
+// job is the goroutine of one enabled schedule.
+type job struct {
+	schedule database.RestartSchedule
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+func New(servers *minecraft.ServerManager, store database.Store) *Scheduler {
+	return &Scheduler{servers: servers, store: store, jobs: make(map[int]job)}
+}
+
+// Reload syncs the running schedules with the database. Only the schedules
+// that were removed, disabled or edited are stopped, a countdown of another
+// schedule keeps going.
+func (sc *Scheduler) Reload() error {
+	schedules, err := sc.store.GetRestartSchedules()
+	if err != nil {
+		return err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	wanted := make(map[int]database.RestartSchedule)
+	for _, schedule := range schedules {
+		if schedule.Enabled {
+			wanted[schedule.ID] = schedule
+		}
+	}
+
+	// 1. Stop what is gone or changed
+	for id, j := range sc.jobs {
+		if schedule, ok := wanted[id]; ok && sameDefinition(schedule, j.schedule) {
+			delete(wanted, id)
+			continue
+		}
+		j.cancel()
+		delete(sc.jobs, id)
+	}
+
+	// 2. Start what is new
+	for id, schedule := range wanted {
+		expr, err := ParseCron(schedule.Cron)
+		if err != nil {
+			log.Printf("[Scheduler] Skipping schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		sc.jobs[id] = job{schedule: schedule, ctx: ctx, cancel: cancel}
+		go sc.run(ctx, schedule, expr)
+	}
+	return nil
+}
+
+// sameDefinition tells whether a schedule fires the same way, LastRun
+// changes on every run and doesn't count.
+func sameDefinition(a, b database.RestartSchedule) bool {
+	return a.InstanceID == b.InstanceID && a.Cron == b.Cron && a.SkipIfOnline == b.SkipIfOnline && a.Enabled == b.Enabled
+}
+
+func (sc *Scheduler) Close() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for id, j := range sc.jobs {
+		j.cancel()
+		delete(sc.jobs, id)
+	}
+}
+
+func (sc *Scheduler) run(ctx context.Context, schedule database.RestartSchedule, expr *Cron) {
+	for {
+		next := expr.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		// Wake up when the first warning is due
+		if !sleepUntil(ctx, next.Add(-Warnings[0])) {
+			return
+		}
+		mc, ok := sc.servers.Get(schedule.InstanceID)
+		if !ok {
+			log.Printf("[Scheduler] Schedule %d: server %q does not exist", schedule.ID, schedule.InstanceID)
+			return
+		}
+		if !sc.restart(ctx, mc, schedule, next) {
+			return
+		}
+	}
+}
+
+// restart runs the countdown and the restart itself. It returns false when
+// the context was cancelled.
+func (sc *Scheduler) restart(ctx context.Context, mc *minecraft.Server, schedule database.RestartSchedule, at time.Time) bool {
+	if mc.GetStatus() != minecraft.StatusRunning {
+		log.Printf("[Scheduler] Schedule %d: server %s is not running, skipping restart", schedule.ID, mc.ID)
+		return sleepUntil(ctx, at)
+	}
+	if skip(mc, schedule) {
+		return sleepUntil(ctx, at)
+	}
+
+	// 1. Countdown
+	for _, warning := range Warnings {
+		if time.Until(at) < warning {
+			continue
+		}
+		if !sleepUntil(ctx, at.Add(-warning)) {
+			return false
+		}
+		announce(mc, warning)
+	}
+	if !sleepUntil(ctx, at) {
+		return false
+	}
+
+	// 2. Players may have joined during the countdown, or an admin stopped it
+	if mc.GetStatus() != minecraft.StatusRunning {
+		log.Printf("[Scheduler] Schedule %d: server %s stopped during the countdown, skipping restart", schedule.ID, mc.ID)
+		return true
+	}
+	if skip(mc, schedule) {
+		return true
+	}
+
+	// 3. Stop + Start
+	mc.Broadcast(fmt.Sprintf("[Scheduler] Scheduled restart (schedule %d)", schedule.ID))
+	stopErr := mc.Stop()
+	if stopErr != nil {
+		log.Printf("[Scheduler] Schedule %d: stopping server %s failed: %v", schedule.ID, mc.ID, stopErr)
+	}
+	// Stop returns once the process is gone, a non-zero exit code included.
+	// Only when even the kill failed the JVM may still write the world, a
+	// second one must not start against it.
+	if status := mc.GetStatus(); status != minecraft.StatusStopped && status != minecraft.StatusCrashed {
+		mc.Broadcast(fmt.Sprintf("[ERROR] Scheduled stop failed, the server is still running: %v", stopErr))
+	} else if err := mc.Start(); err != nil {
+		mc.Broadcast("[ERROR] Scheduled start failed: " + err.Error())
+	}
+	if err := sc.store.SetRestartScheduleLastRun(schedule.ID, time.Now()); err != nil {
+		log.Printf("[Scheduler] Failed to save last run: %v", err)
+	}
+	return true
+}
+
+func skip(mc *minecraft.Server, schedule database.RestartSchedule) bool {
+	if !schedule.SkipIfOnline {
+		return false
+	}
+	count := mc.PlayerCount()
+	if count == 0 {
+		return false
+	}
+	mc.Broadcast(fmt.Sprintf("[Scheduler] Skipping scheduled restart, %d player(s) online", count))
+	return true
+}
+
+func announce(mc *minecraft.Server, left time.Duration) {
+	text := "Server restarts in " + humanDuration(left)
+	mc.SendCommand("say " + text)
+	mc.SendCommand(fmt.Sprintf(`title @a title {"text":"%s","color":"red"}`, text))
+}
+
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= 2*time.Minute:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d >= time.Minute:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d seconds", int(d.Seconds()))
+	}
+}
+
+// sleepUntil returns false if ctx was cancelled first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// NextRun is exposed for the API so admins can see when a schedule fires.
+func NextRun(schedule database.RestartSchedule) *time.Time {
+	if !schedule.Enabled {
+		return nil
+	}
+	expr, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return nil
+	}
+	next := expr.Next(time.Now())
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"paperMC_backend/internal/database"
+	"paperMC_backend/internal/java"
+	"paperMC_backend/internal/minecraft"
+)
+
+func newTestStore(t *testing.T) *database.SQLiteStore {
+	t.Helper()
+	store, err := database.NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("[TEST] NewSQLiteStore error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestReload(t *testing.T) {
+	store := newTestStore(t)
+	servers := minecraft.NewServerManager(store, java.NewRegistry(nil), minecraft.ServerOptions{})
+	t.Cleanup(servers.Close)
+	sc := New(servers, store)
+	defer sc.Close()
+
+	// Far away, the goroutines only sleep
+	kept := &database.RestartSchedule{InstanceID: "default", Cron: "0 4 1 1 *", Enabled: true}
+	edited := &database.RestartSchedule{InstanceID: "default", Cron: "0 5 1 1 *", Enabled: true}
+	removed := &database.RestartSchedule{InstanceID: "creative", Cron: "0 6 1 1 *", Enabled: true}
+	for _, schedule := range []*database.RestartSchedule{kept, edited, removed} {
+		if err := store.CreateRestartSchedule(schedule); err != nil {
+			t.Fatalf("[TEST] CreateRestartSchedule failed: %v", err)
+		}
+	}
+	if err := sc.Reload(); err != nil {
+		t.Fatalf("[TEST] Reload failed: %v", err)
+	}
+	before := make(map[int]context.Context)
+	for id, j := range sc.jobs {
+		before[id] = j.ctx
+	}
+	if len(before) != 3 {
+		t.Fatalf("[TEST] %d schedules running, want: 3", len(before))
+	}
+
+	edited.Cron = "30 5 1 1 *"
+	store.UpdateRestartSchedule(edited)
+	store.DeleteRestartSchedule(removed.ID)
+	store.SetRestartScheduleLastRun(kept.ID, time.Now())
+	if err := sc.Reload(); err != nil {
+		t.Fatalf("[TEST] second Reload failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		id          int
+		wantKept    bool // the same goroutine still runs
+		wantRunning bool
+	}{
+		{"Unchanged, only its last run moved", kept.ID, true, true},
+		{"Edited", edited.ID, false, true},
+		{"Removed", removed.ID, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j, running := sc.jobs[tt.id]
+			if running != tt.wantRunning {
+				t.Fatalf("[TEST] running = %v, want: %v", running, tt.wantRunning)
+			}
+			if kept := running && j.ctx == before[tt.id]; kept != tt.wantKept {
+				t.Errorf("[TEST] kept = %v, want: %v", kept, tt.wantKept)
+			}
+			if cancelled := before[tt.id].Err() != nil; cancelled == tt.wantKept {
+				t.Errorf("[TEST] previous goroutine cancelled = %v, want: %v", cancelled, !tt.wantKept)
+			}
+		})
+	}
+}
+
+// fakeServer returns a server whose "java" boots at once and echoes the
+// console commands, it exits with stopCode on "stop".
+func fakeServer(t *testing.T, stopCode int) *minecraft.Server {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("[TEST] needs /bin/sh")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "java")
+	script := fmt.Sprintf("#!/bin/sh\necho '[12:00:00 INFO]: Done (1.234s)! For help, type \"help\"'\n"+
+		"while read cmd; do echo \"$cmd\"; [ \"$cmd\" = stop ] && exit %d; done\n", stopCode)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("[TEST] Failed to write fake java: %v", err)
+	}
+	s := minecraft.NewServer(dir, "server.jar", "1G", nil)
+	s.Java = java.Runtime{Path: path}
+	s.Restart.Enabled = false
+	t.Cleanup(func() { s.Stop() })
+	return s
+}
+
+func TestRestart(t *testing.T) {
+	warnings := Warnings
+	Warnings = []time.Duration{200 * time.Millisecond, 100 * time.Millisecond}
+	t.Cleanup(func() { Warnings = warnings })
+
+	tests := []struct {
+		name        string
+		started     bool
+		stopCode    int
+		wantSays    int // countdown messages
+		wantRestart bool
+	}{
+		{"Countdown and restart", true, 0, 2, true},
+		{"Stopped server is skipped", false, 0, 0, false},
+		{"Failed stop, the process is gone", true, 3, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			schedule := &database.RestartSchedule{InstanceID: "default", Cron: "* * * * *", Enabled: true}
+			if err := store.CreateRestartSchedule(schedule); err != nil {
+				t.Fatalf("[TEST] CreateRestartSchedule failed: %v", err)
+			}
+			sc := New(nil, store)
+			mc := fakeServer(t, tt.stopCode)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if tt.started {
+				if err := mc.Start(); err != nil {
+					t.Fatalf("[TEST] Start failed: %v", err)
+				}
+				if err := mc.WaitReady(ctx); err != nil {
+					t.Fatalf("[TEST] WaitReady failed: %v", err)
+				}
+			}
+
+			if !sc.restart(ctx, mc, *schedule, time.Now().Add(300*time.Millisecond)) {
+				t.Fatalf("[TEST] restart was cancelled")
+			}
+
+			says := 0
+			for _, line := range mc.GetHistory() {
+				if strings.Contains(line.Text, "say Server restarts in") {
+					says++
+				}
+			}
+			if says != tt.wantSays {
+				t.Errorf("[TEST] %d countdown messages, want: %d", says, tt.wantSays)
+			}
+			restarted := mc.WaitReady(ctx) == nil
+			if restarted != tt.wantRestart {
+				t.Errorf("[TEST] restarted = %v, want: %v (status %s)", restarted, tt.wantRestart, mc.GetStatus())
+			}
+			stored, err := store.GetRestartSchedule(schedule.ID)
+			if err != nil || (stored.LastRun != nil) != tt.wantRestart {
+				t.Errorf("[TEST] last run = %v, %v, want set: %v", stored.LastRun, err, tt.wantRestart)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+// Package slp implements the Server List Ping, what the multiplayer screen
+// of the game uses to show a server: handshake, status JSON, then ping/pong.
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"paperMC_backend/internal/logparse"
+)
+
+// anyProtocol asks for the status without claiming a game version.
+const anyProtocol = -1
+
+// maxPacket bounds what we accept, the status JSON with a favicon is ~20KB.
+const maxPacket = 1 << 21
+
+var errBadPacket = errors.New("slp: malformed packet")
+
+type Version struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+type SamplePlayer struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+type Players struct {
+	Max    int            `json:"max"`
+	Online int            `json:"online"`
+	Sample []SamplePlayer `json:"sample,omitempty"`
+}
+
+// Status is what the server answered.
+type Status struct {
+	Version     Version         `json:"version"`
+	Players     Players         `json:"players"`
+	Description json.RawMessage `json:"description,omitempty"` // a string or a chat component
+	Favicon     string          `json:"favicon,omitempty"`     // data:image/png;base64,...
+	MOTD        string          `json:"motd"`                  // Description as plain text
+	LatencyMs   int64           `json:"latency_ms"`
+}
+
+// Ping asks the server at addr ("host:port") for its status. timeout covers
+// the whole exchange.
+func Ping(addr string, timeout time.Duration) (*Status, error) {
+	host, portText, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portText, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("slp: invalid port %q", portText)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	reader := bufio.NewReader(conn)
+
+	// 1. Handshake with next state 1 (status), then the status request
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, anyProtocol)
+	writeString(&handshake, host)
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1)
+	if err := writePacket(conn, 0x00, handshake.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writePacket(conn, 0x00, nil); err != nil {
+		return nil, err
+	}
+
+	// 2. Status response: one string of JSON
+	id, body, err := readPacket(reader)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0x00 {
+		return nil, errBadPacket
+	}
+	raw, err := readString(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var status Status
+	if err := json.Unmarshal([]byte(raw), &status); err != nil {
+		return nil, fmt.Errorf("slp: status: %w", err)
+	}
+	status.MOTD = MOTD(status.Description)
+
+	// 3. Ping, the pong echoes the payload
+	sent := time.Now()
+	payload := make([]byte, 8)
+	binary.BigEndian.PutUint64(payload, uint64(sent.UnixMilli()))
+	if err := writePacket(conn, 0x01, payload); err != nil {
+		return nil, err
+	}
+	id, body, err = readPacket(reader)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0x01 || !bytes.Equal(body, payload) {
+		return nil, errBadPacket
+	}
+	status.LatencyMs = time.Since(sent).Milliseconds()
+	return &status, nil
+}
+
+// component is the part of a chat component a MOTD uses.
+type component struct {
+	Text  string            `json:"text"`
+	Extra []json.RawMessage `json:"extra"`
+}
+
+// MOTD flattens a description (a string, a component or an array of them)
+// to plain text without § codes.
+func MOTD(description json.RawMessage) string {
+	var out strings.Builder
+	flatten(&out, description)
+	return logparse.Plain(out.String())
+}
+
+func flatten(out *strings.Builder, raw json.RawMessage) {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		out.WriteString(text)
+		return
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil {
+		for _, part := range list {
+			flatten(out, part)
+		}
+		return
+	}
+	var c component
+	if json.Unmarshal(raw, &c) == nil {
+		out.WriteString(c.Text)
+		for _, part := range c.Extra {
+			flatten(out, part)
+		}
+	}
+}
+
+// --- Wire format: packets are VarInt length, VarInt id, data ---
+
+func writePacket(w io.Writer, id int32, data []byte) error {
+	var body bytes.Buffer
+	writeVarInt(&body, id)
+	body.Write(data)
+
+	var packet bytes.Buffer
+	writeVarInt(&packet, int32(body.Len()))
+	packet.Write(body.Bytes())
+	_, err := w.Write(packet.Bytes())
+	return err
+}
+
+func readPacket(r io.ByteReader) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxPacket {
+		return 0, nil, errBadPacket
+	}
+	data := make([]byte, length)
+	for i := range data {
+		if data[i], err = r.ReadByte(); err != nil {
+			return 0, nil, err
+		}
+	}
+	body := bytes.NewReader(data)
+	id, err := readVarInt(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	rest, _ := io.ReadAll(body)
+	return id, rest, nil
+}
+
+func writeVarInt(w *bytes.Buffer, v int32) {
+	u := uint32(v)
+	for u >= 0x80 {
+		w.WriteByte(byte(u) | 0x80)
+		u >>= 7
+	}
+	w.WriteByte(byte(u))
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(v), nil
+		}
+	}
+	return 0, errBadPacket
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	writeVarInt(w, int32(len(s)))
+	w.WriteString(s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || int(n) > r.Len() {
+		return "", errBadPacket
+	}
+	buf := make([]byte, n)
+	io.ReadFull(r, buf)
+	return string(buf), nil
+}
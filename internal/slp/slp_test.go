@@ -0,0 +1,95 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer answers one status exchange like a Paper server.
+func fakeServer(t *testing.T, statusJSON string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+
+		// Handshake, then the status request
+		if id, body, err := readPacket(reader); err != nil || id != 0 || body[len(body)-1] != 1 {
+			t.Errorf("[TEST] bad handshake: %v", err)
+			return
+		}
+		if id, _, err := readPacket(reader); err != nil || id != 0 {
+			t.Errorf("[TEST] bad status request: %v", err)
+			return
+		}
+		var status bytes.Buffer
+		writeString(&status, statusJSON)
+		writePacket(conn, 0x00, status.Bytes())
+
+		// Pong
+		id, payload, err := readPacket(reader)
+		if err != nil || id != 0x01 {
+			t.Errorf("[TEST] bad ping: %v", err)
+			return
+		}
+		writePacket(conn, 0x01, payload)
+	}()
+	return ln.Addr().String()
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		wantMOTD string
+	}{
+		{
+			"String description",
+			`{"version":{"name":"Paper 1.21.4","protocol":769},"players":{"max":20,"online":1,"sample":[{"name":"Steve","id":"069a79f4-44e9-4726-a5be-fca90e38aaf5"}]},"description":"§aA Minecraft Server"}`,
+			"A Minecraft Server",
+		},
+		{
+			"Component description",
+			`{"version":{"name":"Paper 1.21.4","protocol":769},"players":{"max":20,"online":1},"description":{"text":"Hello ","extra":[{"text":"world","color":"gold"},"!"]}}`,
+			"Hello world!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := Ping(fakeServer(t, tt.status), time.Second)
+			if err != nil {
+				t.Fatalf("[TEST] Ping failed: %v", err)
+			}
+			if status.Version.Protocol != 769 || status.Players.Max != 20 || status.Players.Online != 1 {
+				t.Errorf("[TEST] unexpected status %+v", status)
+			}
+			if status.MOTD != tt.wantMOTD {
+				t.Errorf("[TEST] MOTD = %q, want: %q", status.MOTD, tt.wantMOTD)
+			}
+		})
+	}
+}
+
+func TestVarInt(t *testing.T) {
+	for _, v := range []int32{0, 1, 127, 128, 25565, 2147483647, -1} {
+		var buf bytes.Buffer
+		writeVarInt(&buf, v)
+		got, err := readVarInt(&buf)
+		if err != nil || got != v {
+			t.Errorf("[TEST] VarInt %d round trip = %d, %v", v, got, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrRolledBack is returned by Install when the new jar did not boot and the
+// previous one was put back.
+var ErrRolledBack = errors.New("update rolled back")
+
+// Stages of Install, reported to its callback
+const (
+	StageStopping    = "stopping"
+	StageSwapping    = "swapping"
+	StageStarting    = "starting"
+	StageRollingBack = "rolling_back"
+)
+
+// Server is the instance being updated, *minecraft.Server implements it.
+type Server interface {
+	Active() bool // starting, running or stopping
+	Start() error
+	Stop() error
+	WaitReady(ctx context.Context) error
+	Broadcast(msg string)
+}
+
+// BackupPath is where Swap keeps the jar it replaced.
+func BackupPath(jarPath string) string {
+	return jarPath + ".previous"
+}
+
+// Swap moves newJar over jarPath and keeps the old jar at BackupPath. Both
+// steps are renames inside the work dir, jarPath is never half written.
+// hadJar is false on a fresh install.
+func Swap(jarPath, newJar string) (hadJar bool, err error) {
+	if err := os.Rename(jarPath, BackupPath(jarPath)); err == nil {
+		hadJar = true
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to keep the previous jar: %w", err)
+	}
+	if err := os.Rename(newJar, jarPath); err != nil {
+		// Put the old jar back, the server must still be able to start
+		if hadJar {
+			os.Rename(BackupPath(jarPath), jarPath)
+		}
+		return false, fmt.Errorf("failed to install the new jar: %w", err)
+	}
+	return hadJar, nil
+}
+
+// Rollback undoes Swap.
+func Rollback(jarPath string, hadJar bool) error {
+	if !hadJar {
+		return os.Remove(jarPath)
+	}
+	return os.Rename(BackupPath(jarPath), jarPath)
+}
+
+// Install swaps newJar in for jarPath. A running server is stopped first,
+// started on the new jar and given bootTimeout to print "Done". If it doesn't,
+// the previous jar is restored and started again and the error wraps
+// ErrRolledBack. A stopped server only gets the new jar.
+//
+// stage (may be nil) is told every step. ctx only bounds the boot wait, once
+// called Install always leaves a jar that can start.
+func Install(ctx context.Context, srv Server, jarPath, newJar string, bootTimeout time.Duration, stage func(string)) error {
+	if stage == nil {
+		stage = func(string) {}
+	}
+
+	// 1. Stop
+	wasActive := srv.Active()
+	if wasActive {
+		stage(StageStopping)
+		srv.Broadcast("[System] Stopping server for the update...")
+		if err := srv.Stop(); err != nil {
+			if srv.Active() {
+				// Still running on the old jar, nothing to undo
+				os.Remove(newJar)
+				return fmt.Errorf("failed to stop server: %w", err)
+			}
+			// It exited anyway (non-zero exit code), the update goes on
+			srv.Broadcast("[WARN] Server stopped with an error: " + err.Error())
+		}
+	}
+
+	// 2. Swap
+	stage(StageSwapping)
+	hadJar, err := Swap(jarPath, newJar)
+	if err != nil {
+		os.Remove(newJar)
+		if wasActive {
+			// The old jar is still in place, bring it back up
+			if startErr := srv.Start(); startErr != nil {
+				return errors.Join(err, fmt.Errorf("failed to start the server again: %w", startErr))
+			}
+		}
+		return err
+	}
+	if !wasActive {
+		srv.Broadcast("[System] Jar replaced, the server will use it on next start")
+		return nil
+	}
+
+	// 3. Start and wait for "Done"
+	stage(StageStarting)
+	srv.Broadcast("[System] Jar replaced. Starting server...")
+	bootErr := srv.Start()
+	if bootErr == nil {
+		bootCtx, cancel := context.WithTimeout(ctx, bootTimeout)
+		bootErr = srv.WaitReady(bootCtx)
+		cancel()
+	}
+	if bootErr == nil {
+		return nil
+	}
+
+	// 4. Roll back. Stop also cancels a pending auto-restart of the new jar,
+	// its error only means the boot already died.
+	stage(StageRollingBack)
+	srv.Broadcast("[ERROR] New jar failed to boot: " + bootErr.Error() + ". Rolling back...")
+	srv.Stop()
+	if err := Rollback(jarPath, hadJar); err != nil {
+		return fmt.Errorf("%w failed: %v (boot error: %v)", ErrRolledBack, err, bootErr)
+	}
+	if !hadJar {
+		return fmt.Errorf("%w: %v", ErrRolledBack, bootErr)
+	}
+	if err := srv.Start(); err != nil {
+		return fmt.Errorf("%w but the previous jar did not start: %v (boot error: %v)", ErrRolledBack, err, bootErr)
+	}
+	return fmt.Errorf("%w: %v", ErrRolledBack, bootErr)
+}
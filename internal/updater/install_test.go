@@ -0,0 +1,179 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer boots when the jar in place is not "broken".
+type fakeServer struct {
+	jarPath  string
+	active   bool
+	booted   []string // content of the jar at every start
+	startErr error    // returned by every Start when set
+	stopErr  error    // returned by Stop when set
+	stuck    bool     // Stop leaves the process running
+}
+
+func (f *fakeServer) Active() bool         { return f.active }
+func (f *fakeServer) Broadcast(msg string) {}
+
+func (f *fakeServer) Start() error {
+	if f.startErr != nil {
+		return f.startErr
+	}
+	data, err := os.ReadFile(f.jarPath)
+	if err != nil {
+		return err
+	}
+	f.booted = append(f.booted, string(data))
+	f.active = true
+	return nil
+}
+
+func (f *fakeServer) Stop() error {
+	if !f.active {
+		return errors.New("server already stopped")
+	}
+	f.active = f.stuck
+	return f.stopErr
+}
+
+func (f *fakeServer) WaitReady(ctx context.Context) error {
+	if f.booted[len(f.booted)-1] == "broken" {
+		f.active = false
+		return errors.New("server failed to start: exit status 1")
+	}
+	return nil
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		name       string
+		oldJar     string // "" for a fresh install
+		newJar     string
+		active     bool
+		wantErr    error
+		wantJar    string
+		wantBooted []string
+	}{
+		{"Running server boots the new jar", "old", "new", true, nil, "new", []string{"new"}},
+		{"Broken jar is rolled back", "old", "broken", true, ErrRolledBack, "old", []string{"broken", "old"}},
+		{"Stopped server only gets the jar", "old", "new", false, nil, "new", nil},
+		{"Broken fresh install is removed", "", "broken", true, ErrRolledBack, "", []string{"broken"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			jarPath := filepath.Join(dir, "server.jar")
+			if tt.oldJar != "" {
+				os.WriteFile(jarPath, []byte(tt.oldJar), 0644)
+			}
+			newPath := filepath.Join(dir, ".paper.jar.download")
+			os.WriteFile(newPath, []byte(tt.newJar), 0644)
+
+			srv := &fakeServer{jarPath: jarPath, active: tt.active}
+			err := Install(context.Background(), srv, jarPath, newPath, time.Second, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("[TEST] err = %v, want: %v", err, tt.wantErr)
+			}
+
+			data, _ := os.ReadFile(jarPath)
+			if string(data) != tt.wantJar {
+				t.Errorf("[TEST] jar = %q, want: %q", data, tt.wantJar)
+			}
+			if len(srv.booted) != len(tt.wantBooted) {
+				t.Fatalf("[TEST] booted %v, want: %v", srv.booted, tt.wantBooted)
+			}
+			for i := range srv.booted {
+				if srv.booted[i] != tt.wantBooted[i] {
+					t.Errorf("[TEST] booted %v, want: %v", srv.booted, tt.wantBooted)
+				}
+			}
+			if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+				t.Errorf("[TEST] download left at %s", newPath)
+			}
+		})
+	}
+}
+
+func TestInstallSwapFailure(t *testing.T) {
+	portInUse := errors.New("port in use")
+	tests := []struct {
+		name       string
+		startErr   error
+		wantBooted []string
+	}{
+		{"Old jar started again", nil, []string{"old"}},
+		{"Old jar does not start", portInUse, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			jarPath := filepath.Join(dir, "server.jar")
+			os.WriteFile(jarPath, []byte("old"), 0644)
+
+			// The download is gone, the rename fails
+			srv := &fakeServer{jarPath: jarPath, active: true, startErr: tt.startErr}
+			err := Install(context.Background(), srv, jarPath, filepath.Join(dir, "missing.jar"), time.Second, nil)
+			if err == nil || !strings.Contains(err.Error(), "failed to install the new jar") {
+				t.Fatalf("[TEST] err = %v, want the swap error", err)
+			}
+			// Both failures reach the job
+			if tt.startErr != nil && !errors.Is(err, tt.startErr) {
+				t.Errorf("[TEST] err = %v, want it to wrap: %v", err, tt.startErr)
+			}
+
+			if data, _ := os.ReadFile(jarPath); string(data) != "old" {
+				t.Errorf("[TEST] jar = %q, want: %q", data, "old")
+			}
+			if strings.Join(srv.booted, ",") != strings.Join(tt.wantBooted, ",") {
+				t.Errorf("[TEST] booted %v, want: %v", srv.booted, tt.wantBooted)
+			}
+		})
+	}
+}
+
+func TestInstallStopFailure(t *testing.T) {
+	exitCode := errors.New("exited with code 1")
+	tests := []struct {
+		name       string
+		stuck      bool
+		wantErr    error
+		wantJar    string
+		wantBooted []string
+	}{
+		{"Exited with an error", false, nil, "new", []string{"new"}},
+		{"Still running", true, exitCode, "old", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			jarPath := filepath.Join(dir, "server.jar")
+			os.WriteFile(jarPath, []byte("old"), 0644)
+			newPath := filepath.Join(dir, ".paper.jar.download")
+			os.WriteFile(newPath, []byte("new"), 0644)
+
+			srv := &fakeServer{jarPath: jarPath, active: true, stopErr: exitCode, stuck: tt.stuck}
+			err := Install(context.Background(), srv, jarPath, newPath, time.Second, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("[TEST] err = %v, want: %v", err, tt.wantErr)
+			}
+
+			if data, _ := os.ReadFile(jarPath); string(data) != tt.wantJar {
+				t.Errorf("[TEST] jar = %q, want: %q", data, tt.wantJar)
+			}
+			if strings.Join(srv.booted, ",") != strings.Join(tt.wantBooted, ",") {
+				t.Errorf("[TEST] booted %v, want: %v", srv.booted, tt.wantBooted)
+			}
+			if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+				t.Errorf("[TEST] download left at %s", newPath)
+			}
+		})
+	}
+}
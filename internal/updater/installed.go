@@ -0,0 +1,261 @@
+package updater
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"paperMC_backend/internal/jarfile"
+)
+
+// Where Installed was read from
+const (
+	SourceHistory  = "version_history.json"
+	SourceManifest = "manifest"
+	SourceVersion  = "version.json"
+)
+
+// Installed is the Paper version and build of a work dir.
+type Installed struct {
+	Version string `json:"version"`          // Minecraft version, "1.21.4"
+	Build   int    `json:"build,omitempty"`  // 0 when the source doesn't tell
+	Commit  string `json:"commit,omitempty"` // short git hash of the build
+	Source  string `json:"source"`
+}
+
+var (
+	// "1.21.4-232-12d5b5c (MC: 1.21.4)", the Implementation-Version of recent builds
+	newVersionRe = regexp.MustCompile(`^(\d+\.\d+(?:\.\d+)?(?:-(?:pre|rc)\d+)?)-(\d+)-([0-9a-f]+)`)
+	// "git-Paper-232 (MC: 1.20.4)", older builds
+	oldVersionRe = regexp.MustCompile(`git-Paper-(\d+)`)
+	mcVersionRe  = regexp.MustCompile(`\(MC: ([^)]+)\)`)
+)
+
+// parseVersionString reads what Paper prints as its version.
+func parseVersionString(s string) Installed {
+	var inst Installed
+	if m := newVersionRe.FindStringSubmatch(s); m != nil {
+		inst.Version = m[1]
+		inst.Build, _ = strconv.Atoi(m[2])
+		inst.Commit = m[3]
+	} else if m := oldVersionRe.FindStringSubmatch(s); m != nil {
+		inst.Build, _ = strconv.Atoi(m[1])
+	}
+	if m := mcVersionRe.FindStringSubmatch(s); m != nil {
+		inst.Version = m[1]
+	}
+	return inst
+}
+
+// jarCache avoids unpacking the jar on every /status, keyed by its path.
+var (
+	jarCacheMu sync.Mutex
+	jarCache   = map[string]jarEntry{}
+)
+
+type jarEntry struct {
+	size      int64
+	modTime   time.Time
+	installed *Installed
+}
+
+// DetectInstalled tells which Paper build sits in workDir. Paper writes
+// version_history.json when it boots, it is trusted when it is newer than the
+// jar. Otherwise the jar's manifest (build number and version) is read, then
+// its version.json (version only). nil when nothing tells.
+func DetectInstalled(workDir, jarFile string) *Installed {
+	jarPath := filepath.Join(workDir, jarFile)
+	jarInfo, jarErr := os.Stat(jarPath)
+
+	// 1. version_history.json, cheap and written by the server itself
+	if info, err := os.Stat(filepath.Join(workDir, "version_history.json")); err == nil &&
+		(jarErr != nil || !info.ModTime().Before(jarInfo.ModTime())) {
+		if inst := readHistory(filepath.Join(workDir, "version_history.json")); inst != nil {
+			return inst
+		}
+	}
+	if jarErr != nil {
+		return nil
+	}
+
+	// 2. The jar, read again only when it changed
+	jarCacheMu.Lock()
+	defer jarCacheMu.Unlock()
+	if entry, ok := jarCache[jarPath]; ok && entry.size == jarInfo.Size() && entry.modTime.Equal(jarInfo.ModTime()) {
+		return entry.installed
+	}
+	inst := readJar(jarPath)
+	jarCache[jarPath] = jarEntry{size: jarInfo.Size(), modTime: jarInfo.ModTime(), installed: inst}
+	return inst
+}
+
+func readHistory(path string) *Installed {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var history struct {
+		CurrentVersion string `json:"currentVersion"`
+	}
+	if json.Unmarshal(data, &history) != nil {
+		return nil
+	}
+	inst := parseVersionString(history.CurrentVersion)
+	if inst.Version == "" {
+		return nil
+	}
+	inst.Source = SourceHistory
+	return &inst
+}
+
+// readJar looks at the jar and only when it doesn't tell, for Paperclip, at
+// the server jar nested under META-INF/versions/.
+func readJar(jarPath string) *Installed {
+	jar, err := jarfile.Open(jarPath)
+	if err != nil {
+		return nil
+	}
+	defer jar.Close()
+
+	// 1. The jar itself
+	if inst := readManifest(jar); inst != nil {
+		return inst
+	}
+	if inst := readVersionJSON(jar); inst != nil {
+		return inst
+	}
+
+	// 2. The nested jars, manifest first
+	var nested []*jarfile.Jar
+	for _, f := range jar.Nested() {
+		if inner, err := jar.OpenNested(f); err == nil {
+			defer inner.Close()
+			nested = append(nested, inner)
+		}
+	}
+	for _, inner := range nested {
+		if inst := readManifest(inner); inst != nil {
+			return inst
+		}
+	}
+	for _, inner := range nested {
+		if inst := readVersionJSON(inner); inst != nil {
+			return inst
+		}
+	}
+	return nil
+}
+
+func readManifest(jar *jarfile.Jar) *Installed {
+	f := jar.Find("META-INF/MANIFEST.MF")
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	attrs := make(map[string]string)
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), ":"); ok {
+			attrs[key] = strings.TrimSpace(value)
+		}
+	}
+	if attrs["Implementation-Version"] == "" {
+		return nil
+	}
+
+	// "1.21.4-232-12d5b5c", or "git-Paper-232" next to Specification-Version "1.20.4-R0.1-SNAPSHOT"
+	inst := parseVersionString(attrs["Implementation-Version"])
+	if build, err := strconv.Atoi(attrs["Build-Number"]); err == nil {
+		inst.Build = build
+	}
+	if commit := attrs["Git-Commit"]; commit != "" && inst.Commit == "" {
+		inst.Commit = commit
+	}
+	if inst.Version == "" {
+		inst.Version, _, _ = strings.Cut(attrs["Specification-Version"], "-R")
+	}
+	if inst.Version == "" {
+		return nil
+	}
+	inst.Source = SourceManifest
+	return &inst
+}
+
+func readVersionJSON(jar *jarfile.Jar) *Installed {
+	f := jar.Find("version.json")
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+
+	var v struct {
+		ID string `json:"id"`
+	}
+	if json.NewDecoder(rc).Decode(&v) != nil || v.ID == "" {
+		return nil
+	}
+	return &Installed{Version: v.ID, Source: SourceVersion}
+}
+
+// CompareVersions orders Minecraft versions: -1 when a is older than b, 0
+// when equal, 1 when newer. "1.21.10" is newer than "1.21.4", a pre-release
+// ("1.21.5-pre1") is older than its release, "1.21.5-pre10" is newer than
+// "1.21.5-pre2".
+func CompareVersions(a, b string) int {
+	aMain, aPre, _ := strings.Cut(a, "-")
+	bMain, bPre, _ := strings.Cut(b, "-")
+	aParts, bParts := strings.Split(aMain, "."), strings.Split(bMain, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	// "pre" comes before "rc", then the numbers: "pre10" is after "pre2"
+	aKind, aNum := splitPre(aPre)
+	bKind, bNum := splitPre(bPre)
+	if c := strings.Compare(aKind, bKind); c != 0 {
+		return c
+	}
+	return cmp.Compare(aNum, bNum)
+}
+
+// splitPre cuts a pre-release suffix, "pre10", into "pre" and 10.
+func splitPre(pre string) (string, int) {
+	kind := strings.TrimRight(pre, "0123456789")
+	n, _ := strconv.Atoi(pre[len(kind):])
+	return kind, n
+}
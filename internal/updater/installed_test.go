@@ -0,0 +1,105 @@
+package updater
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeJar creates a zip with the given entries and returns its bytes.
+func writeJar(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("[TEST] Failed to create %s: %v", name, err)
+		}
+		f.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("[TEST] Failed to close jar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectInstalled(t *testing.T) {
+	paper := writeJar(t, map[string]string{
+		"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nImplementation-Title: Paper\nImplementation-Version: 1.21.4-232-12d5b5c\nBuild-Number: 232\n",
+		"version.json":         `{"id":"1.21.4","java_version":21}`,
+	})
+	oldPaper := writeJar(t, map[string]string{
+		"META-INF/MANIFEST.MF": "Implementation-Version: git-Paper-496\nSpecification-Version: 1.20.4-R0.1-SNAPSHOT\n",
+	})
+	vanilla := writeJar(t, map[string]string{"version.json": `{"id":"1.21.4","java_version":21}`})
+	paperclip := writeJar(t, map[string]string{
+		"META-INF/MANIFEST.MF":                      "Main-Class: io.papermc.paperclip.Main\n",
+		"META-INF/versions/1.21.4/paper-1.21.4.jar": string(paper),
+	})
+
+	tests := []struct {
+		name    string
+		jar     []byte
+		history string // written after the jar unless stale
+		stale   bool   // history older than the jar
+		want    *Installed
+	}{
+		{"Paperclip nested manifest", paperclip, "", false, &Installed{Version: "1.21.4", Build: 232, Commit: "12d5b5c", Source: SourceManifest}},
+		{"Old manifest", oldPaper, "", false, &Installed{Version: "1.20.4", Build: 496, Source: SourceManifest}},
+		{"version.json only", vanilla, "", false, &Installed{Version: "1.21.4", Source: SourceVersion}},
+		{"version_history.json", paperclip, `{"currentVersion":"1.21.3-83-de6a5b4 (MC: 1.21.3)"}`, false, &Installed{Version: "1.21.3", Build: 83, Commit: "de6a5b4", Source: SourceHistory}},
+		{"Stale history after a swap", paperclip, `{"currentVersion":"git-Paper-496 (MC: 1.20.4)"}`, true, &Installed{Version: "1.21.4", Build: 232, Commit: "12d5b5c", Source: SourceManifest}},
+		{"No jar", nil, "", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			jarTime := time.Now().Add(-time.Hour)
+			if tt.jar != nil {
+				os.WriteFile(filepath.Join(dir, "server.jar"), tt.jar, 0644)
+				os.Chtimes(filepath.Join(dir, "server.jar"), jarTime, jarTime)
+			}
+			if tt.history != "" {
+				historyTime := jarTime.Add(time.Minute)
+				if tt.stale {
+					historyTime = jarTime.Add(-time.Minute)
+				}
+				os.WriteFile(filepath.Join(dir, "version_history.json"), []byte(tt.history), 0644)
+				os.Chtimes(filepath.Join(dir, "version_history.json"), historyTime, historyTime)
+			}
+
+			got := DetectInstalled(dir, "server.jar")
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("[TEST] DetectInstalled = %+v, want: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.21.10", "1.21.4", 1},
+		{"1.21.4", "1.21.4", 0},
+		{"1.20.6", "1.21", -1},
+		{"1.21", "1.21.0", 0},
+		{"1.21.5-pre1", "1.21.5", -1},
+		{"1.21.5-rc1", "1.21.5-pre3", 1},
+		{"1.21-pre10", "1.21-pre2", 1},
+		{"1.21-pre2", "1.21-pre10", -1},
+		{"1.21-rc10", "1.21-rc9", 1},
+		{"1.21-pre10", "1.21-rc1", -1},
+		{"1.21-pre3", "1.21-pre3", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("[TEST] CompareVersions(%q, %q) = %d, want: %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,109 +1,319 @@
+// Package updater finds and downloads Paper builds from PaperMC's download
+// API, Fill v3:
+//
+//	GET {base}/v3/projects/{project}/versions
+//	GET {base}/v3/projects/{project}/versions/{version}/builds
+//	GET {base}/v3/projects/{project}/versions/{version}/builds/{build}
 package updater
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
-// Endpoint: https://api.papermc.io/v2/projects/paper/versions/<version>/builds
+// DefaultBaseURL is the public Fill API, PAPER_API_URL points elsewhere (a mirror, a test server).
+const DefaultBaseURL = "https://fill.papermc.io"
 
-// BuildsResponse represents the top-level JSON object
-type BuildsResponse struct {
-	ProjectId   string  `json:"project_id"`
-	ProjectName string  `json:"project_name"`
-	Version     string  `json:"version"`
-	Builds      []Build `json:"builds"`
+// apiTimeout bounds the JSON calls, a jar download only follows its context
+const apiTimeout = 30 * time.Second
+
+// serverDownload is the key of the server jar in Build.Downloads
+const serverDownload = "server:default"
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrChecksum = errors.New("downloaded jar does not match its checksum")
+)
+
+// Channel is the release channel of a build, from the most to the least stable.
+type Channel string
+
+const (
+	ChannelRecommended Channel = "RECOMMENDED" // a stable build promoted by the Paper team
+	ChannelStable      Channel = "STABLE"
+	ChannelBeta        Channel = "BETA"
+	ChannelAlpha       Channel = "ALPHA"
+)
+
+var channelRank = map[Channel]int{ChannelRecommended: -1, ChannelStable: 0, ChannelBeta: 1, ChannelAlpha: 2}
+
+// ParseChannel accepts a channel name in any case, "" is STABLE.
+func ParseChannel(name string) (Channel, error) {
+	if name == "" {
+		return ChannelStable, nil
+	}
+	channel := Channel(strings.ToUpper(name))
+	if _, ok := channelRank[channel]; !ok {
+		return "", fmt.Errorf("unknown channel %q, use RECOMMENDED, STABLE, BETA or ALPHA", name)
+	}
+	return channel, nil
+}
+
+// Allows reports whether a build of channel other may be installed when
+// following c: STABLE accepts RECOMMENDED and STABLE builds, BETA also beta
+// builds and ALPHA everything.
+func (c Channel) Allows(other Channel) bool {
+	rank, ok := channelRank[other]
+	return ok && rank <= channelRank[c]
+}
+
+type Version struct {
+	ID      string  `json:"id"`
+	Support Support `json:"support"`
+	Java    Java    `json:"java"`
+}
+
+type Support struct {
+	Status string `json:"status"` // "SUPPORTED", "DEPRECATED", "UNSUPPORTED"
+	End    string `json:"end,omitempty"`
+}
+
+type Java struct {
+	Version struct {
+		Minimum int `json:"minimum"`
+	} `json:"version"`
+	Flags struct {
+		Recommended []string `json:"recommended"`
+	} `json:"flags"`
+}
+
+// VersionBuilds is one entry of the versions list.
+type VersionBuilds struct {
+	Version Version `json:"version"`
+	Builds  []int   `json:"builds"` // newest first
 }
 
-// Build represents a single entry in the "builds" list
 type Build struct {
-	Build     int       `json:"build"`
-	Time      string    `json:"time"`
-	Channel   string    `json:"channel"`
-	Promoted  bool      `json:"promoted"`
-	Changes   []Change  `json:"changes"`
-	Downloads Downloads `json:"downloads"`
+	ID        int                 `json:"id"`
+	Time      time.Time           `json:"time"`
+	Channel   Channel             `json:"channel"`
+	Commits   []Commit            `json:"commits"`
+	Downloads map[string]Download `json:"downloads"`
+}
+
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+type Download struct {
+	Name      string    `json:"name"`
+	Checksums Checksums `json:"checksums"`
+	Size      int64     `json:"size"`
+	URL       string    `json:"url"`
 }
 
-type Change struct {
-	Commit  string `json:"commit"`
-	Summary string `json:"summary"`
-	Message string `json:"message"`
+type Checksums struct {
+	SHA256 string `json:"sha256"`
 }
 
-type Downloads struct {
-	Application Application `json:"application"`
+// Server returns the server jar of the build.
+func (b Build) Server() (Download, error) {
+	download, ok := b.Downloads[serverDownload]
+	if !ok || download.URL == "" {
+		return Download{}, fmt.Errorf("build %d has no server download", b.ID)
+	}
+	return download, nil
+}
+
+// Client talks to a Fill API.
+type Client struct {
+	BaseURL   string
+	Project   string // "paper"
+	UserAgent string // Fill asks every client to identify itself
+	HTTP      *http.Client
 }
 
-type Application struct {
-	Name   string `json:"name"`
-	Sha256 string `json:"sha256"`
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		Project:   "paper",
+		UserAgent: "paperMC_backend (https://github.com/qju/paperMC_backend)",
+		HTTP:      &http.Client{},
+	}
 }
 
-func GetLatestBuild(version string) (int, string, string, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	url := fmt.Sprintf("https://api.papermc.io/v2/projects/paper/versions/%s/builds", version)
+// Versions lists the versions of the project, newest first.
+func (c *Client) Versions(ctx context.Context) ([]VersionBuilds, error) {
+	var resp struct {
+		Versions []VersionBuilds `json:"versions"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/v3/projects/%s/versions", c.Project), &resp)
+	return resp.Versions, err
+}
 
-	resp, err := client.Get(url)
+// Builds lists the builds of a version that channel allows, newest first.
+func (c *Client) Builds(ctx context.Context, version string, channel Channel) ([]Build, error) {
+	var all []Build
+	if err := c.get(ctx, fmt.Sprintf("/v3/projects/%s/versions/%s/builds", c.Project, url.PathEscape(version)), &all); err != nil {
+		return nil, err
+	}
+	builds := []Build{}
+	for _, build := range all {
+		if channel.Allows(build.Channel) {
+			builds = append(builds, build)
+		}
+	}
+	return builds, nil
+}
+
+// Build returns one build, for pinning.
+func (c *Client) Build(ctx context.Context, version string, id int) (Build, error) {
+	var build Build
+	err := c.get(ctx, fmt.Sprintf("/v3/projects/%s/versions/%s/builds/%d", c.Project, url.PathEscape(version), id), &build)
+	return build, err
+}
+
+// LatestBuild returns the newest build of a version that channel allows.
+func (c *Client) LatestBuild(ctx context.Context, version string, channel Channel) (Build, error) {
+	builds, err := c.Builds(ctx, version, channel)
+	if err != nil {
+		return Build{}, err
+	}
+	// Don't trust the order, the highest ID is the newest
+	var latest *Build
+	for i := range builds {
+		if latest == nil || builds[i].ID > latest.ID {
+			latest = &builds[i]
+		}
+	}
+	if latest == nil {
+		return Build{}, fmt.Errorf("no %s build for version %s: %w", channel, version, ErrNotFound)
+	}
+	return *latest, nil
+}
+
+// Download saves the server jar of build to a temporary file in dir and
+// returns its path. The file is only kept when its size and SHA-256 match
+// what the API announced, the caller renames or removes it.
+// progress (may be nil) is called after every write.
+func (c *Client) Download(ctx context.Context, build Build, dir string, progress func(bytes, total int64)) (string, error) {
+	download, err := build.Server()
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(ctx, download.URL)
 	if err != nil {
-		return 0, "", "", fmt.Errorf("failed to fetch builds: %w", err)
+		return "", fmt.Errorf("failed to download jar: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, "", "", fmt.Errorf("api error: %d", resp.StatusCode)
+	file, err := os.CreateTemp(dir, "."+filepath.Base(download.Name)+".*.download")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
 	}
+	keep := false
+	defer func() {
+		file.Close()
+		if !keep {
+			os.Remove(file.Name())
+		}
+	}()
 
-	var result BuildsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, "", "", fmt.Errorf("invalid JSON: %w", err)
+	// 1. Hash while writing, the jar is only read once
+	hash := sha256.New()
+	var dst io.Writer = io.MultiWriter(file, hash)
+	if progress != nil {
+		total := download.Size
+		if total <= 0 {
+			total = resp.ContentLength
+		}
+		dst = &progressWriter{w: dst, total: total, report: progress}
+	}
+	size, err := io.Copy(dst, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
-	if len(result.Builds) == 0 {
-		return 0, "", "", fmt.Errorf("no builds found for version: %s", version)
+	// 2. Verify before anything touches the running server
+	if download.Size > 0 && size != download.Size {
+		return "", fmt.Errorf("%w: got %d bytes, want %d", ErrChecksum, size, download.Size)
 	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, download.Checksums.SHA256) {
+		return "", fmt.Errorf("%w: sha256 %s, want %s", ErrChecksum, sum, download.Checksums.SHA256)
+	}
+
+	// 3. On disk for real before the swap
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	keep = true
+	return file.Name(), nil
+}
 
-	// The API returns build sorted by time, so the last one is the latest
-	latest := result.Builds[len(result.Builds)-1]
-	return latest.Build,
-		latest.Downloads.Application.Name,
-		latest.Downloads.Application.Sha256,
-		nil
+// progressWriter counts what goes through it.
+type progressWriter struct {
+	w      io.Writer
+	done   int64
+	total  int64
+	report func(bytes, total int64)
 }
 
-func DownloadJar(version string, build int, fileName string, targetPath string) error {
-	client := &http.Client{}
-	url := fmt.Sprintf(
-		"https://api.papermc.io/v2/projects/paper/versions/%s/builds/%d/downloads/%s",
-		version, build, fileName,
-	)
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.done += int64(n)
+	p.report(p.done, p.total)
+	return n, err
+}
 
-	resp, err := client.Get(url)
+// get decodes the JSON answer of a path below BaseURL.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+	resp, err := c.do(ctx, c.BaseURL+path)
 	if err != nil {
-		return fmt.Errorf("failed to download jar: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("error when downloading jar: %d", resp.StatusCode)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
 	}
+	return nil
+}
 
-	fullPath := filepath.Join(targetPath, fileName)
-	file, err := os.Create(fullPath)
+// do sends a GET and turns a non 200 answer into an error.
+func (c *Client) do(ctx context.Context, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
-
-	_, err = io.Copy(file, resp.Body)
+	req.Header.Set("User-Agent", c.UserAgent)
+	resp, err := c.HTTP.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
 	}
+	defer resp.Body.Close()
 
-	return nil
+	// Fill explains errors as {"error": "...", "message": "..."}
+	var apiErr struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+	detail := apiErr.Message
+	if detail == "" {
+		detail = apiErr.Error
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("api error: %s: %w", detail, ErrNotFound)
+	}
+	return nil, fmt.Errorf("api error: %d %s", resp.StatusCode, detail)
 }
@@ -0,0 +1,216 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var jar = []byte("PK fake paper jar")
+
+// fakeFill serves the 1.21.4 builds newest first, like Fill does.
+func fakeFill(t *testing.T) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	sum := sha256.Sum256(jar)
+	build := func(id int, channel Channel) Build {
+		name := fmt.Sprintf("paper-1.21.4-%d.jar", id)
+		return Build{
+			ID:      id,
+			Channel: channel,
+			Commits: []Commit{{SHA: "abc123", Message: "Build " + name}},
+			Downloads: map[string]Download{serverDownload: {
+				Name:      name,
+				Checksums: Checksums{SHA256: hex.EncodeToString(sum[:])},
+				Size:      int64(len(jar)),
+				URL:       srv.URL + "/files/" + name,
+			}},
+		}
+	}
+	builds := []Build{build(232, ChannelAlpha), build(231, ChannelBeta), build(230, ChannelRecommended), build(229, ChannelStable)}
+
+	mux.HandleFunc("GET /v3/projects/paper/versions/{version}/builds", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, `{"error":"missing user agent"}`, http.StatusBadRequest)
+			return
+		}
+		if r.PathValue("version") != "1.21.4" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Version not found."}`))
+			return
+		}
+		json.NewEncoder(w).Encode(builds)
+	})
+	mux.HandleFunc("GET /v3/projects/paper/versions/1.21.4/builds/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, b := range builds {
+			if fmt.Sprint(b.ID) == r.PathValue("id") {
+				json.NewEncoder(w).Encode(b)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not_found","message":"Build not found."}`))
+	})
+	mux.HandleFunc("GET /v3/projects/paper/versions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"versions":[{"version":{"id":"1.21.4","support":{"status":"SUPPORTED"},"java":{"version":{"minimum":21},"flags":{"recommended":["-XX:+UseG1GC"]}}},"builds":[232,231,230,229]}]}`))
+	})
+	mux.HandleFunc("GET /files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(jar)
+	})
+	return NewClient(srv.URL + "/")
+}
+
+func TestLatestBuild(t *testing.T) {
+	client := fakeFill(t)
+	tests := []struct {
+		name    string
+		version string
+		channel Channel
+		want    int
+		wantErr error
+	}{
+		{"Stable takes recommended, skips newer betas", "1.21.4", ChannelStable, 230, nil},
+		{"Beta takes stable and beta", "1.21.4", ChannelBeta, 231, nil},
+		{"Alpha takes everything", "1.21.4", ChannelAlpha, 232, nil},
+		{"Unknown version", "1.99", ChannelStable, 0, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build, err := client.LatestBuild(context.Background(), tt.version, tt.channel)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("[TEST] err = %v, want: %v", err, tt.wantErr)
+			}
+			if build.ID != tt.want {
+				t.Errorf("[TEST] build = %d, want: %d", build.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestPinnedBuild(t *testing.T) {
+	client := fakeFill(t)
+	ctx := context.Background()
+
+	build, err := client.Build(ctx, "1.21.4", 229)
+	if err != nil {
+		t.Fatalf("[TEST] Build failed: %v", err)
+	}
+	if build.ID != 229 || build.Channel != ChannelStable || len(build.Commits) != 1 {
+		t.Errorf("[TEST] unexpected build %+v", build)
+	}
+	if _, err := client.Build(ctx, "1.21.4", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("[TEST] missing build err = %v, want: ErrNotFound", err)
+	}
+
+	// The pinned jar lands in a temporary file of the directory
+	dir := t.TempDir()
+	path, err := client.Download(ctx, build, dir, nil)
+	if err != nil {
+		t.Fatalf("[TEST] Download failed: %v", err)
+	}
+	if filepath.Dir(path) != dir {
+		t.Errorf("[TEST] downloaded to %s, want a file in %s", path, dir)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != string(jar) {
+		t.Errorf("[TEST] downloaded %q, want: %q", data, jar)
+	}
+	hash, _ := GetFileHash(path)
+	if server, _ := build.Server(); hash != server.Checksums.SHA256 {
+		t.Errorf("[TEST] hash = %s, want: %s", hash, server.Checksums.SHA256)
+	}
+}
+
+func TestDownloadChecksum(t *testing.T) {
+	client := fakeFill(t)
+	build, err := client.Build(context.Background(), "1.21.4", 230)
+	if err != nil {
+		t.Fatalf("[TEST] Build failed: %v", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(*Download)
+	}{
+		{"Wrong hash", func(d *Download) { d.Checksums.SHA256 = strings.Repeat("0", 64) }},
+		{"Wrong size", func(d *Download) { d.Size++ }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			download := build.Downloads[serverDownload]
+			tt.modify(&download)
+			corrupt := build
+			corrupt.Downloads = map[string]Download{serverDownload: download}
+
+			dir := t.TempDir()
+			if _, err := client.Download(context.Background(), corrupt, dir, nil); !errors.Is(err, ErrChecksum) {
+				t.Fatalf("[TEST] err = %v, want: ErrChecksum", err)
+			}
+			// Nothing is left behind
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("[TEST] %d files left in the directory", len(entries))
+			}
+		})
+	}
+}
+
+func TestVersions(t *testing.T) {
+	versions, err := fakeFill(t).Versions(context.Background())
+	if err != nil {
+		t.Fatalf("[TEST] Versions failed: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version.ID != "1.21.4" || versions[0].Version.Java.Version.Minimum != 21 || len(versions[0].Builds) != 4 {
+		t.Errorf("[TEST] unexpected versions %+v", versions)
+	}
+}
+
+func TestChannelAllows(t *testing.T) {
+	tests := []struct {
+		follow, build Channel
+		want          bool
+	}{
+		{ChannelStable, ChannelRecommended, true},
+		{ChannelStable, ChannelStable, true},
+		{ChannelStable, ChannelBeta, false},
+		{ChannelRecommended, ChannelStable, false},
+		{ChannelBeta, ChannelRecommended, true},
+		{ChannelAlpha, ChannelAlpha, true},
+		{ChannelAlpha, "SNAPSHOT", false},
+	}
+	for _, tt := range tests {
+		if got := tt.follow.Allows(tt.build); got != tt.want {
+			t.Errorf("[TEST] %s.Allows(%s) = %v, want: %v", tt.follow, tt.build, got, tt.want)
+		}
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Channel
+		wantErr bool
+	}{
+		{"", ChannelStable, false},
+		{"beta", ChannelBeta, false},
+		{"Recommended", ChannelRecommended, false},
+		{"ALPHA", ChannelAlpha, false},
+		{"nightly", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChannel(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("[TEST] ParseChannel(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
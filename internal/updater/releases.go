@@ -0,0 +1,114 @@
+package updater
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Release is a build as the build browser shows it.
+type Release struct {
+	Build     int       `json:"build"`
+	Time      time.Time `json:"time"`
+	Channel   Channel   `json:"channel"`
+	Promoted  bool      `json:"promoted"`  // RECOMMENDED by the Paper team
+	Latest    bool      `json:"latest"`    // what /update picks on the followed channel
+	Installed bool      `json:"installed"` // the build in the work dir
+	Changes   []Change  `json:"changes"`
+	Download  *Download `json:"download,omitempty"`
+}
+
+// Change is one commit of a build's changelog.
+type Change struct {
+	Commit  string    `json:"commit"`
+	Summary string    `json:"summary"` // first line of the message
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// VersionRelease is a Minecraft version as the version browser shows it.
+type VersionRelease struct {
+	Version     string `json:"version"`
+	Support     string `json:"support"`      // "SUPPORTED", "DEPRECATED", "UNSUPPORTED"
+	Java        int    `json:"java"`         // minimum Java release
+	Builds      int    `json:"builds"`       // number of builds
+	LatestBuild int    `json:"latest_build"` // newest build on any channel
+	Installed   bool   `json:"installed"`
+	Newer       bool   `json:"newer"` // newer than the installed version
+}
+
+// Releases turns the builds of version into the browser's view, newest first.
+// follow is the channel /update uses, installedHash the SHA-256 of the
+// current jar for when the installed build number is unknown.
+func Releases(version string, builds []Build, follow Channel, installed *Installed, installedHash string) []Release {
+	releases := make([]Release, 0, len(builds))
+	latest := 0
+	for _, build := range builds {
+		if follow.Allows(build.Channel) && build.ID > latest {
+			latest = build.ID
+		}
+	}
+
+	for _, build := range builds {
+		release := Release{
+			Build:    build.ID,
+			Time:     build.Time,
+			Channel:  build.Channel,
+			Promoted: build.Channel == ChannelRecommended,
+			Latest:   build.ID == latest,
+			Changes:  make([]Change, 0, len(build.Commits)),
+		}
+		for _, commit := range build.Commits {
+			summary, _, _ := strings.Cut(commit.Message, "\n")
+			release.Changes = append(release.Changes, Change{
+				Commit:  commit.SHA,
+				Summary: strings.TrimSpace(summary),
+				Message: commit.Message,
+				Time:    commit.Time,
+			})
+		}
+		download, err := build.Server()
+		if err == nil {
+			release.Download = &download
+		}
+
+		// Installed: the build number when known, else the jar's hash
+		if installed != nil && installed.Version == version {
+			if installed.Build > 0 {
+				release.Installed = installed.Build == build.ID
+			} else {
+				release.Installed = err == nil && installedHash != "" && strings.EqualFold(installedHash, download.Checksums.SHA256)
+			}
+		}
+		releases = append(releases, release)
+	}
+
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Build > releases[j].Build })
+	return releases
+}
+
+// VersionReleases turns the versions list into the browser's view, newest first.
+func VersionReleases(versions []VersionBuilds, installed *Installed) []VersionRelease {
+	releases := make([]VersionRelease, 0, len(versions))
+	for _, v := range versions {
+		release := VersionRelease{
+			Version: v.Version.ID,
+			Support: v.Version.Support.Status,
+			Java:    v.Version.Java.Version.Minimum,
+			Builds:  len(v.Builds),
+		}
+		for _, id := range v.Builds {
+			release.LatestBuild = max(release.LatestBuild, id)
+		}
+		if installed != nil {
+			release.Installed = installed.Version == v.Version.ID
+			release.Newer = CompareVersions(v.Version.ID, installed.Version) > 0
+		}
+		releases = append(releases, release)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return CompareVersions(releases[i].Version, releases[j].Version) > 0
+	})
+	return releases
+}
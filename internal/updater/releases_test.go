@@ -0,0 +1,83 @@
+package updater
+
+import (
+	"testing"
+)
+
+func TestReleases(t *testing.T) {
+	download := func(sum string) map[string]Download {
+		return map[string]Download{serverDownload: {Name: "paper.jar", Checksums: Checksums{SHA256: sum}, URL: "http://fill/paper.jar"}}
+	}
+	builds := []Build{
+		{ID: 230, Channel: ChannelStable, Downloads: download("aaa"), Commits: []Commit{{SHA: "c1", Message: "Fix chunk loading\n\nLonger explanation"}}},
+		{ID: 232, Channel: ChannelAlpha, Downloads: download("ccc")},
+		{ID: 229, Channel: ChannelRecommended, Downloads: download("999")},
+		{ID: 231, Channel: ChannelBeta, Downloads: download("bbb")},
+	}
+
+	tests := []struct {
+		name          string
+		follow        Channel
+		installed     *Installed
+		hash          string
+		wantLatest    int
+		wantInstalled int // 0 for none
+	}{
+		{"Installed by build number", ChannelStable, &Installed{Version: "1.21.4", Build: 229}, "", 230, 229},
+		{"Installed by hash", ChannelBeta, &Installed{Version: "1.21.4", Source: SourceVersion}, "BBB", 231, 231},
+		{"Other version installed", ChannelAlpha, &Installed{Version: "1.21.3", Build: 230}, "", 232, 0},
+		{"Nothing installed", ChannelStable, nil, "", 230, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releases := Releases("1.21.4", builds, tt.follow, tt.installed, tt.hash)
+			if len(releases) != 4 || releases[0].Build != 232 || releases[3].Build != 229 {
+				t.Fatalf("[TEST] releases not newest first: %+v", releases)
+			}
+			for _, r := range releases {
+				if r.Latest != (r.Build == tt.wantLatest) {
+					t.Errorf("[TEST] build %d latest = %v, want latest: %d", r.Build, r.Latest, tt.wantLatest)
+				}
+				if r.Installed != (r.Build == tt.wantInstalled) {
+					t.Errorf("[TEST] build %d installed = %v, want installed: %d", r.Build, r.Installed, tt.wantInstalled)
+				}
+				if r.Promoted != (r.Build == 229) {
+					t.Errorf("[TEST] build %d promoted = %v", r.Build, r.Promoted)
+				}
+			}
+		})
+	}
+
+	// The changelog keeps the message and its first line
+	changes := Releases("1.21.4", builds, ChannelStable, nil, "")[2].Changes
+	if len(changes) != 1 || changes[0].Summary != "Fix chunk loading" || changes[0].Commit != "c1" {
+		t.Errorf("[TEST] unexpected changes %+v", changes)
+	}
+}
+
+func TestVersionReleases(t *testing.T) {
+	versions := []VersionBuilds{
+		{Version: Version{ID: "1.21.4"}, Builds: []int{232, 231}},
+		{Version: Version{ID: "1.21.10"}, Builds: []int{12}},
+		{Version: Version{ID: "1.20.6"}, Builds: []int{151}},
+	}
+	releases := VersionReleases(versions, &Installed{Version: "1.21.4"})
+	want := []struct {
+		version   string
+		installed bool
+		newer     bool
+	}{
+		{"1.21.10", false, true},
+		{"1.21.4", true, false},
+		{"1.20.6", false, false},
+	}
+	for i, w := range want {
+		r := releases[i]
+		if r.Version != w.version || r.Installed != w.installed || r.Newer != w.newer {
+			t.Errorf("[TEST] releases[%d] = %+v, want: %+v", i, r, w)
+		}
+	}
+	if releases[1].LatestBuild != 232 || releases[1].Builds != 2 {
+		t.Errorf("[TEST] 1.21.4 latest = %d of %d builds", releases[1].LatestBuild, releases[1].Builds)
+	}
+}
@@ -1,11 +1,19 @@
 import Anser from 'anser';
+import type { LogRecord } from '../hooks/useSocket';
 
-export function LogLine({ content }: { content: string }) {
+type Props = { content: string; error?: boolean; level?: string; record?: LogRecord };
+
+export function LogLine({ content, error = false, level, record }: Props) {
     // 1. Determine the "Base Color" of the line
-    // This color is used for any text that doesn't have its own ANSI color code.
+    // This color is used for any text that doesn't have its own color.
     let baseColorClass = "text-gray-300"; // <--- CHANGE THIS for your default color
 
-    if (content.includes("ERROR") || content.includes("Exception")) {
+    if (level) {
+        // The backend parsed the line, no guessing
+        if (error || level === "ERROR" || level === "FATAL") baseColorClass = "text-red-400";
+        else if (level === "WARN") baseColorClass = "text-mc-gold";
+        else if (level === "INFO") baseColorClass = "text-blue-300";
+    } else if (error || content.includes("ERROR") || content.includes("Exception")) {
         baseColorClass = "text-red-400";
     } else if (content.includes("WARN")) {
         baseColorClass = "text-mc-gold";
@@ -13,14 +21,38 @@ export function LogLine({ content }: { content: string }) {
         baseColorClass = "text-blue-300"; // Your preferred INFO color
     }
 
-    // 2. Parse the ANSI codes
+    // 2. Structured line: render the spans the backend sent
+    if (record) {
+        return (
+            <div className={`font-mono text-[13px] leading-tight break-words whitespace-pre-wrap ${baseColorClass}`}>
+                {record.level && <span>[{record.level}] </span>}
+                {record.logger && <span className="text-gray-400">[{record.logger}] </span>}
+                {record.spans.map((span, i) => (
+                    <span
+                        key={i}
+                        style={{
+                            color: span.color,
+                            fontWeight: span.bold ? 'bold' : 'normal',
+                            fontStyle: span.italic ? 'italic' : undefined,
+                            textDecoration: [span.underline && 'underline', span.strikethrough && 'line-through']
+                                .filter(Boolean).join(' ') || undefined,
+                        }}
+                    >
+                        {span.text}
+                    </span>
+                ))}
+            </div>
+        );
+    }
+
+    // 3. Parse the ANSI codes
     const chunks = Anser.ansiToJson(content, {
         use_classes: false,
         json: true
     });
 
     return (
-        // 3. Apply the base color to the wrapper DIV
+        // 4. Apply the base color to the wrapper DIV
         <div className={`font-mono text-[13px] leading-tight break-words whitespace-pre-wrap ${baseColorClass}`}>
             {chunks.map((chunk, i) => (
                 <span
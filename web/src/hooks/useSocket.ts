@@ -1,52 +1,102 @@
 import { useEffect, useRef, useState } from 'react';
 
+// Paper line split by the backend, see logparse.Record
+export type LogRecord = {
+    time?: string;
+    level?: string;
+    thread?: string;
+    logger?: string; // plugin of "[LuckPerms] message"
+    message: string;
+    spans: LogSpan[];
+};
+
+export type LogSpan = {
+    text: string;
+    color?: string; // "#rrggbb"
+    bold?: boolean;
+    italic?: boolean;
+    underline?: boolean;
+    strikethrough?: boolean;
+    obfuscated?: boolean;
+};
+
 type LogMessage = {
-    type: 'log' | 'error';
+    type: 'log' | 'error' | 'gap';
     data: string;
+    seq?: number; // console lines only, the cursor to resume from
+    stream?: 'stdout' | 'stderr' | 'system';
+    error?: boolean; // stderr lines, highlighted by the console
+    level?: string;
+    payload?: LogRecord;
+};
+
+export type LogEntry = {
+    data: string;
+    error: boolean;
+    level?: string;
+    record?: LogRecord;
 };
 
 export function useSocket() {
     const [isConnected, setIsConnected] = useState(false);
-    const [logs, setLogs] = useState<string[]>([]);
+    const [logs, setLogs] = useState<LogEntry[]>([]);
 
     // We use ref because we need to talk to the *same* useSocket
     // across different render of the component.
     const socketRef = useRef<WebSocket | null>(null);
+    // Last console line we got, so a reconnect only replays what we missed
+    const cursorRef = useRef(0);
 
     useEffect(() => {
         // 1. Security Check: Do we have a token?
         const token = localStorage.getItem('token');
         if (!token) return;
 
-        // 2. Build the URL (Handle SSL automatically)
-        const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-        const wsUrl = `${protocol}//${window.location.host}/ws?token=${token}`;
-
-        // 3. Open connection
-        const ws = new WebSocket(wsUrl);
-        socketRef.current = ws;
-
-        // 4. Handle Event
-        ws.onopen = () => setIsConnected(true);
-        ws.onclose = () => setIsConnected(false);
-
-        // THE IMPORTANT PART: Receving data
-        ws.onmessage = (event) => {
-            try {
-                const msg: LogMessage = JSON.parse(event.data);
-                if (msg.type === 'log') {
-                    // Functoin State Update:
-                    // "Tate the previous list, add the new line at the end"
-                    setLogs((prev) => [...prev, msg.data]);
+        let closed = false;
+        let retry: ReturnType<typeof setTimeout> | undefined;
+
+        const connect = () => {
+            // 2. Build the URL (Handle SSL automatically)
+            const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
+            const wsUrl = `${protocol}//${window.location.host}/ws?token=${token}&cursor=${cursorRef.current}`;
+
+            // 3. Open connection
+            const ws = new WebSocket(wsUrl);
+            socketRef.current = ws;
+
+            // 4. Handle Event
+            ws.onopen = () => setIsConnected(true);
+            ws.onclose = () => {
+                setIsConnected(false);
+                // Reconnect and resume from the cursor, unless we are leaving the page
+                if (!closed) retry = setTimeout(connect, 2000);
+            };
+
+            // THE IMPORTANT PART: Receving data
+            ws.onmessage = (event) => {
+                try {
+                    const msg: LogMessage = JSON.parse(event.data);
+                    if (msg.seq) cursorRef.current = msg.seq;
+                    if (msg.type === 'gap') {
+                        // The backend skipped lines (slow connection or a long disconnect), say so in the console
+                        setLogs((prev) => [...prev, { data: msg.data, error: false }]);
+                    } else if (msg.type === 'log') {
+                        // Functoin State Update:
+                        // "Tate the previous list, add the new line at the end"
+                        setLogs((prev) => [...prev, { data: msg.data, error: !!msg.error, level: msg.level, record: msg.payload }]);
+                    }
+                } catch (err) {
+                    console.error("WS Parse Error", err);
                 }
-            } catch (err) {
-                console.error("WS Parse Error", err);
-            }
+            };
         };
+        connect();
 
         // 5. Cleanup; if the user leave the page, kill connection
         return () => {
-            ws.close();
+            closed = true;
+            clearTimeout(retry);
+            socketRef.current?.close();
         };
 
     }, []); // [] means "Run this onece when the component mounts"
@@ -76,7 +76,7 @@ export default function Console() {
                 )}
 
                 {logs.map((line, index) => (
-                    <LogLine key={index} content={line} />
+                    <LogLine key={index} content={line.data} error={line.error} level={line.level} record={line.record} />
                     //    <div key={index} className="break-words leading-relaxed font-mono text-sm">
                     //        {/* Basic Syntax Highlighting */}
                     //        {line.includes("ERROR") || line.includes("Exception") ? (